@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/openclaw/cam-agent/internal/attach"
+	"github.com/openclaw/cam-agent/internal/session"
+)
+
+func sessionAttach(args []string) {
+	id := flagStr(args, "--id", "")
+	sock := flagStr(args, "--unix", "")
+	if id == "" {
+		fatal("usage: cam-agent session attach --id X [--unix /path.sock]")
+	}
+	if sock == "" {
+		sock = fmt.Sprintf("/tmp/cam-agent-attach-%s.sock", id)
+	}
+
+	mgr := session.NewManager()
+	if !mgr.Exists(id) {
+		fatal(fmt.Sprintf("session attach: session %q does not exist", id))
+	}
+
+	os.Remove(sock)
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		fatalErr("session attach: listen", err)
+	}
+	defer os.Remove(sock)
+
+	writeJSON(map[string]any{"ok": true, "unix": sock})
+
+	conn, err := ln.Accept()
+	if err != nil {
+		fatalErr("session attach: accept", err)
+	}
+	ln.Close()
+	defer conn.Close()
+
+	// The client disconnecting (io.EOF from the conn read loop) ends the
+	// attach the same way closing an interactive shell does — it's not
+	// a failure worth a non-zero exit.
+	if err := attach.Serve(context.Background(), conn, mgr, id); err != nil && !errors.Is(err, io.EOF) {
+		fatalErr("session attach", err)
+	}
+}
+
+func sessionRecv(args []string) {
+	sock := flagStr(args, "--sock", "")
+	if sock == "" {
+		fatal("usage: cam-agent session recv --sock /path.sock")
+	}
+
+	conn, err := attach.Dial(sock)
+	if err != nil {
+		fatalErr("session recv", err)
+	}
+	defer conn.Close()
+
+	fd := int(os.Stdin.Fd())
+	restore, err := attach.RawMode(fd)
+	if err != nil {
+		fatalErr("session recv: raw mode", err)
+	}
+	defer restore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigc
+		cancel()
+		conn.Close()
+	}()
+
+	// A clean hangup (the agent closing conn, or stdin EOF) surfaces as
+	// io.EOF; that's a normal end to the session, not an error.
+	if err := attach.Bridge(ctx, conn, os.Stdin, os.Stdout); err != nil && !errors.Is(err, io.EOF) {
+		restore()
+		fatalErr("session recv", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/openclaw/cam-agent/internal/ninep"
+)
+
+func cmdFs(sub string, args []string) {
+	switch sub {
+	case "serve":
+		fsServe(args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown fs subcommand: %s\n", sub)
+		os.Exit(1)
+	}
+}
+
+func fsServe(args []string) {
+	root := flagStr(args, "--root", "")
+	listen := flagStr(args, "--listen", "")
+	secret := flagStr(args, "--secret", "")
+	ro := flagBool(args, "--ro")
+
+	if root == "" || listen == "" {
+		fatal("usage: cam-agent fs serve --root /path --listen unix:/path|tcp:host:port [--ro] [--secret S]\n" +
+			"note: this serves classic 9P2000, not 9P2000.L; mount with -o version=9p2000")
+	}
+
+	srv, err := ninep.NewServer(ninep.Options{Root: root, ReadOnly: ro, Secret: secret})
+	if err != nil {
+		fatalErr("fs serve", err)
+	}
+
+	network, address, err := ninep.ParseListen(listen)
+	if err != nil {
+		fatalErr("fs serve", err)
+	}
+
+	if network == "unix" {
+		os.Remove(address)
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		fatalErr("fs serve", err)
+	}
+	defer ln.Close()
+	if network == "unix" {
+		defer os.Remove(address)
+	}
+
+	fmt.Fprintf(os.Stderr, "cam-agent: exporting %s over 9P2000 on %s\n", root, listen)
+	if err := srv.Serve(ln); err != nil {
+		fatalErr("fs serve", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/openclaw/cam-agent/internal/fileops"
+	"github.com/openclaw/cam-agent/internal/manifest"
 	"github.com/openclaw/cam-agent/internal/session"
 )
 
@@ -26,7 +27,7 @@ func main() {
 		cmdPing()
 	case "session":
 		if len(os.Args) < 3 {
-			fmt.Fprintln(os.Stderr, "usage: cam-agent session <create|exists|kill|capture|send|key|log-start|log-read>")
+			fmt.Fprintln(os.Stderr, "usage: cam-agent session <create|apply|exists|kill|capture|send|key|log-start|log-read|attach|recv>")
 			os.Exit(1)
 		}
 		cmdSession(os.Args[2], os.Args[3:])
@@ -36,6 +37,16 @@ func main() {
 			os.Exit(1)
 		}
 		cmdFile(os.Args[2], os.Args[3:])
+	case "fs":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "usage: cam-agent fs serve --root /path --listen unix:/path|tcp:host:port")
+			os.Exit(1)
+		}
+		cmdFs(os.Args[2], os.Args[3:])
+	case "serve":
+		cmdServe(os.Args[2:])
+	case "call":
+		cmdCall(os.Args[2:])
 	case "--version", "version":
 		fmt.Println(version)
 	case "--help", "help":
@@ -53,16 +64,24 @@ func usage() {
 	fmt.Fprintln(os.Stderr, "Commands:")
 	fmt.Fprintln(os.Stderr, "  ping                          Test connection + version")
 	fmt.Fprintln(os.Stderr, "  session create --id X --workdir /path [--env-setup '...'] -- cmd args")
+	fmt.Fprintln(os.Stderr, "  session apply --file sess.yaml   (multi-window/pane layout)")
 	fmt.Fprintln(os.Stderr, "  session exists --id X")
 	fmt.Fprintln(os.Stderr, "  session kill --id X")
-	fmt.Fprintln(os.Stderr, "  session capture --id X [--lines 100]")
-	fmt.Fprintln(os.Stderr, "  session send --id X --text 'hello' [--no-enter]")
+	fmt.Fprintln(os.Stderr, "  session capture --id X [--lines 100] [--format text|ansi|cells|html] [--pane W.P]")
+	fmt.Fprintln(os.Stderr, "  session send --id X --text 'hello' [--no-enter] [--pane W.P]")
 	fmt.Fprintln(os.Stderr, "  session key --id X --key Enter")
 	fmt.Fprintln(os.Stderr, "  session log-start --id X")
 	fmt.Fprintln(os.Stderr, "  session log-read --id X [--offset N] [--max-bytes N]")
+	fmt.Fprintln(os.Stderr, "  session attach --id X [--unix /path.sock]")
+	fmt.Fprintln(os.Stderr, "  session recv --sock /path.sock  (puts local tty in raw mode)")
 	fmt.Fprintln(os.Stderr, "  file list --path /dir")
 	fmt.Fprintln(os.Stderr, "  file read --path /file [--max-bytes N]")
 	fmt.Fprintln(os.Stderr, "  file write --path /file  (reads stdin)")
+	fmt.Fprintln(os.Stderr, "  file pack --path /dir [--exclude glob]... [--gz]  (writes tar to stdout)")
+	fmt.Fprintln(os.Stderr, "  file unpack --path /dir [--overwrite] [--max-bytes N]  (reads tar from stdin)")
+	fmt.Fprintln(os.Stderr, "  fs serve --root /path --listen unix:/path|tcp:host:port [--ro] [--secret S]")
+	fmt.Fprintln(os.Stderr, "  serve --listen host:port | --unix /path [--token T] [--tls-cert C --tls-key K]")
+	fmt.Fprintln(os.Stderr, "  call <verb> [--unix /path | --addr host:port] [--token T] [flags...]")
 }
 
 // --- ping ---
@@ -95,6 +114,12 @@ func cmdSession(sub string, args []string) {
 		sessionLogStart(args)
 	case "log-read":
 		sessionLogRead(args)
+	case "apply":
+		sessionApply(args)
+	case "attach":
+		sessionAttach(args)
+	case "recv":
+		sessionRecv(args)
 	default:
 		fmt.Fprintf(os.Stderr, "unknown session subcommand: %s\n", sub)
 		os.Exit(1)
@@ -133,6 +158,24 @@ func sessionCreate(args []string) {
 	writeJSON(map[string]any{"ok": true})
 }
 
+func sessionApply(args []string) {
+	file := flagStr(args, "--file", "")
+	if file == "" {
+		fatal("usage: cam-agent session apply --file sess.yaml")
+	}
+
+	spec, err := manifest.Load(file)
+	if err != nil {
+		fatalErr("session apply", err)
+	}
+
+	mgr := session.NewManager()
+	if err := mgr.CreateLayout(spec.ID, spec); err != nil {
+		fatalErr("session apply", err)
+	}
+	writeJSON(map[string]any{"ok": true, "id": spec.ID})
+}
+
 func sessionExists(args []string) {
 	id := flagStr(args, "--id", "")
 	if id == "" {
@@ -163,16 +206,29 @@ func sessionKill(args []string) {
 func sessionCapture(args []string) {
 	id := flagStr(args, "--id", "")
 	lines := flagInt(args, "--lines", 100)
+	format := flagStr(args, "--format", "text")
+	pane := flagStr(args, "--pane", "0.0")
 	if id == "" {
-		fatal("usage: cam-agent session capture --id X [--lines N]")
+		fatal("usage: cam-agent session capture --id X [--lines N] [--format text|ansi|cells|html] [--pane W.P]")
 	}
 
 	mgr := session.NewManager()
-	output, err := mgr.Capture(id, lines)
+
+	var output string
+	var err error
+	if pane != "0.0" {
+		if format != "" && format != "text" {
+			fatal("--pane only supports --format text")
+		}
+		output, err = mgr.CapturePane(id, pane, lines)
+	} else {
+		output, err = mgr.CaptureFormatted(id, lines, format)
+	}
 	if err != nil {
 		fatalErr("session capture", err)
 	}
-	// Plain text output (hot path — no JSON encoding overhead)
+	// Plain text output (hot path — no JSON encoding overhead); for
+	// --format cells this is already a JSON document.
 	fmt.Print(output)
 }
 
@@ -180,12 +236,13 @@ func sessionSend(args []string) {
 	id := flagStr(args, "--id", "")
 	text := flagStr(args, "--text", "")
 	noEnter := flagBool(args, "--no-enter")
+	pane := flagStr(args, "--pane", "0.0")
 	if id == "" {
-		fatal("usage: cam-agent session send --id X --text 'hello' [--no-enter]")
+		fatal("usage: cam-agent session send --id X --text 'hello' [--no-enter] [--pane W.P]")
 	}
 
 	mgr := session.NewManager()
-	if err := mgr.SendText(id, text, !noEnter); err != nil {
+	if err := mgr.SendTextPane(id, pane, text, !noEnter); err != nil {
 		fatalErr("session send", err)
 	}
 	writeJSON(map[string]any{"ok": true})
@@ -247,6 +304,10 @@ func cmdFile(sub string, args []string) {
 		fileRead(args)
 	case "write":
 		fileWrite(args)
+	case "pack":
+		filePack(args)
+	case "unpack":
+		fileUnpack(args)
 	default:
 		fmt.Fprintf(os.Stderr, "unknown file subcommand: %s\n", sub)
 		os.Exit(1)
@@ -297,6 +358,40 @@ func fileWrite(args []string) {
 	writeJSON(map[string]any{"ok": true})
 }
 
+func filePack(args []string) {
+	path := flagStr(args, "--path", "")
+	if path == "" {
+		fatal("usage: cam-agent file pack --path /dir [--exclude glob]... [--gz]")
+	}
+
+	opts := fileops.PackOpts{Exclude: flagAll(args, "--exclude")}
+	if flagBool(args, "--gz") {
+		opts.Compression = "gzip"
+	}
+
+	if err := fileops.Pack(path, opts, os.Stdout); err != nil {
+		fatalErr("file pack", err)
+	}
+}
+
+func fileUnpack(args []string) {
+	path := flagStr(args, "--path", "")
+	maxBytes := flagInt64(args, "--max-bytes", 0)
+	if path == "" {
+		fatal("usage: cam-agent file unpack --path /dir [--overwrite] [--max-bytes N]")
+	}
+
+	opts := fileops.UnpackOpts{
+		Overwrite: flagBool(args, "--overwrite"),
+		MaxBytes:  maxBytes,
+	}
+
+	if err := fileops.Unpack(path, os.Stdin, opts); err != nil {
+		fatalErr("file unpack", err)
+	}
+	writeJSON(map[string]any{"ok": true})
+}
+
 // --- helpers ---
 
 func writeJSON(v any) {
@@ -348,6 +443,18 @@ func flagInt64(args []string, name string, def int64) int64 {
 	return v
 }
 
+// flagAll returns the values of every occurrence of a repeatable flag,
+// e.g. --exclude a --exclude b -> ["a", "b"].
+func flagAll(args []string, name string) []string {
+	var out []string
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == name {
+			out = append(out, args[i+1])
+		}
+	}
+	return out
+}
+
 func flagBool(args []string, name string) bool {
 	for _, a := range args {
 		if a == name {
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/openclaw/cam-agent/internal/daemon"
+	"github.com/openclaw/cam-agent/internal/session"
+)
+
+// --- serve ---
+
+func cmdServe(args []string) {
+	listen := flagStr(args, "--listen", "")
+	unix := flagStr(args, "--unix", "")
+	token := flagStr(args, "--token", "")
+	tlsCert := flagStr(args, "--tls-cert", "")
+	tlsKey := flagStr(args, "--tls-key", "")
+
+	if listen == "" && unix == "" {
+		fatal("usage: cam-agent serve --listen host:port | --unix /path [--token T] [--tls-cert C --tls-key K]")
+	}
+
+	mgr := session.NewManager()
+	opts := daemon.Options{
+		Listen:  listen,
+		Unix:    unix,
+		Token:   token,
+		TLSCert: tlsCert,
+		TLSKey:  tlsKey,
+	}
+	if err := daemon.Serve(mgr, opts); err != nil {
+		fatalErr("serve", err)
+	}
+}
+
+// --- call ---
+
+func cmdCall(args []string) {
+	if len(args) < 1 {
+		fatal("usage: cam-agent call <verb> [--unix /path | --addr host:port] [--token T] [flags...]")
+	}
+	verb := args[0]
+	rest := args[1:]
+
+	addr := flagStr(rest, "--addr", "")
+	unix := flagStr(rest, "--unix", "")
+	token := flagStr(rest, "--token", "")
+	useTLS := flagBool(rest, "--tls")
+
+	client, err := daemon.NewClient(daemon.ClientOptions{
+		Addr:  addr,
+		Unix:  unix,
+		Token: token,
+		TLS:   useTLS,
+	})
+	if err != nil {
+		fatalErr("call", err)
+	}
+
+	switch verb {
+	case "session/capture", "session/log-read":
+		if flagBool(rest, "--stream") {
+			callStream(client, verb, rest)
+		} else {
+			callOnce(client, verb, rest)
+		}
+	default:
+		callOnce(client, verb, rest)
+	}
+}
+
+// callOnce makes a single request, building the query/body from the
+// remaining flags, and prints the raw response body to stdout.
+func callOnce(client *daemon.Client, verb string, args []string) {
+	query, body := buildCallPayload(verb, args)
+
+	data, err := client.Call(context.Background(), verb, query, body)
+	if err != nil {
+		fatalErr("call "+verb, err)
+	}
+	os.Stdout.Write(data)
+	fmt.Println()
+}
+
+// callStream drives the NDJSON streaming endpoints, printing each chunk's
+// bytes to stdout as it arrives.
+func callStream(client *daemon.Client, verb string, args []string) {
+	query, _ := buildCallPayload(verb, args)
+
+	err := client.Stream(context.Background(), verb, query, func(_ int64, data []byte) {
+		os.Stdout.Write(data)
+	})
+	if err != nil {
+		fatalErr("call "+verb, err)
+	}
+}
+
+// buildCallPayload maps CLI flags onto the query string (GET verbs) or a
+// JSON body (mutating verbs; file/write instead sends its path in the
+// query and stdin as a raw body), mirroring the request shapes the daemon
+// handlers expect.
+func buildCallPayload(verb string, args []string) (url.Values, any) {
+	q := url.Values{}
+	setIf := func(name string) {
+		if v := flagStr(args, "--"+name, ""); v != "" {
+			q.Set(name, v)
+		}
+	}
+
+	switch verb {
+	case "ping":
+		return q, nil
+	case "session/exists":
+		setIf("id")
+		return q, nil
+	case "session/capture":
+		setIf("id")
+		setIf("lines")
+		setIf("format")
+		setIf("pane")
+		setIf("stream")
+		return q, nil
+	case "session/log-read":
+		setIf("id")
+		setIf("offset")
+		setIf("max_bytes")
+		setIf("stream")
+		return q, nil
+	case "file/list":
+		setIf("path")
+		return q, nil
+	case "file/read":
+		setIf("path")
+		setIf("max_bytes")
+		return q, nil
+	case "file/write":
+		setIf("path")
+		return q, os.Stdin
+	case "session/create":
+		var cmdArgs []string
+		for i, a := range args {
+			if a == "--" {
+				cmdArgs = args[i+1:]
+				break
+			}
+		}
+		return nil, map[string]any{
+			"id":        flagStr(args, "--id", ""),
+			"workdir":   flagStr(args, "--workdir", ""),
+			"env_setup": flagStr(args, "--env-setup", ""),
+			"cmd":       cmdArgs,
+		}
+	case "session/apply":
+		data, err := os.ReadFile(flagStr(args, "--file", ""))
+		if err != nil {
+			fatalErr("call session/apply", err)
+		}
+		return nil, map[string]any{"manifest": string(data)}
+	case "session/kill", "session/log-start":
+		return nil, map[string]any{"id": flagStr(args, "--id", "")}
+	case "session/send":
+		return nil, map[string]any{
+			"id":       flagStr(args, "--id", ""),
+			"text":     flagStr(args, "--text", ""),
+			"no_enter": flagBool(args, "--no-enter"),
+			"pane":     flagStr(args, "--pane", ""),
+		}
+	case "session/key":
+		return nil, map[string]any{
+			"id":  flagStr(args, "--id", ""),
+			"key": flagStr(args, "--key", ""),
+		}
+	default:
+		return q, nil
+	}
+}
@@ -0,0 +1,126 @@
+//go:build linux || darwin
+
+package attach
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Dial connects to the unix socket an agent's "session attach" opened.
+func Dial(sockPath string) (net.Conn, error) {
+	conn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		return nil, fmt.Errorf("attach: dial %s: %w", sockPath, err)
+	}
+	return conn, nil
+}
+
+// winsize mirrors struct winsize from <sys/ioctl.h>.
+type winsize struct {
+	Row, Col, Xpixel, Ypixel uint16
+}
+
+func getWinsize(fd int) (rows, cols uint16, err error) {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+	return ws.Row, ws.Col, nil
+}
+
+// syncFrameWriter serializes writeFrame calls from multiple goroutines
+// onto one conn. writeFrame writes a frame's header and payload as two
+// separate Writes; without a lock held across both, a frameResize
+// written by the SIGWINCH goroutine can land between another frame's
+// header and payload and desync the stream for the rest of the session.
+type syncFrameWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncFrameWriter) writeFrame(typ byte, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFrame(s.w, typ, payload)
+}
+
+// Bridge copies stdin to conn and conn to stdout as frameData, and
+// forwards the local terminal's size (initially, then on every SIGWINCH)
+// to the agent as frameResize. It blocks until either side closes.
+func Bridge(ctx context.Context, conn net.Conn, stdin io.Reader, stdout io.Writer) error {
+	sw := &syncFrameWriter{w: conn}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if rows, cols, err := getWinsize(int(os.Stdin.Fd())); err == nil {
+		sw.writeFrame(frameResize, encodeResize(rows, cols))
+	}
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGWINCH)
+	defer signal.Stop(sigc)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigc:
+				if rows, cols, err := getWinsize(int(os.Stdin.Fd())); err == nil {
+					sw.writeFrame(frameResize, encodeResize(rows, cols))
+				}
+			}
+		}
+	}()
+
+	errc := make(chan error, 2)
+	go func() { errc <- copyToFrames(sw, stdin) }()
+	go func() { errc <- copyFromFrames(stdout, conn) }()
+
+	err := <-errc
+	cancel()
+	conn.Close()
+	<-done
+	return err
+}
+
+func copyToFrames(sw *syncFrameWriter, stdin io.Reader) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := stdin.Read(buf)
+		if n > 0 {
+			if werr := sw.writeFrame(frameData, buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func copyFromFrames(stdout io.Writer, conn net.Conn) error {
+	for {
+		typ, payload, err := readFrame(conn)
+		if err != nil {
+			return err
+		}
+		if typ == frameData {
+			if _, err := stdout.Write(payload); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package attach lets a client get a live, bidirectional channel to a
+// running tmux session instead of polling session.Manager's Capture/
+// SendText in a loop.
+//
+// tmux owns the pane's real pty, so there's no local pty master fd for
+// the agent to hand a client via SCM_RIGHTS here (unlike, say, runc
+// recvtty attaching to a container's console). Instead the agent side
+// (Serve) proxies a single framed byte stream over a unix socket: pane
+// output (tailed from the same log file session.Manager.ReadLog uses)
+// flows to the client, and client keystrokes flow back out through
+// SendText. This is the "framed byte protocol" fallback the wire format
+// below implements uniformly rather than as a platform-specific case.
+package attach
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Frame types.
+const (
+	frameData   = 1 // payload: raw bytes, either direction
+	frameResize = 2 // payload: 4 bytes, rows(uint16) then cols(uint16); client -> server only
+)
+
+// writeFrame writes one type-prefixed, length-prefixed frame.
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	hdr := make([]byte, 5)
+	hdr[0] = typ
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)))
+	if _, err := w.Write(hdr); err != nil {
+		return err
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// readFrame reads one frame written by writeFrame.
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > 16*1024*1024 {
+		return 0, nil, fmt.Errorf("attach: frame too large (%d bytes)", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], payload, nil
+}
+
+func encodeResize(rows, cols uint16) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint16(b[0:], rows)
+	binary.BigEndian.PutUint16(b[2:], cols)
+	return b
+}
+
+func decodeResize(payload []byte) (rows, cols uint16, ok bool) {
+	if len(payload) != 4 {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint16(payload[0:]), binary.BigEndian.Uint16(payload[2:]), true
+}
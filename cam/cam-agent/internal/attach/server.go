@@ -0,0 +1,123 @@
+package attach
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openclaw/cam-agent/internal/session"
+)
+
+// pollInterval is how often Serve re-checks the session's log file for
+// new output once it has drained everything currently available.
+const pollInterval = 50 * time.Millisecond
+
+// Serve proxies a single client connection to the named session: pane
+// output is tailed and forwarded as frameData, client frameData payloads
+// are sent into the pane as literal keystrokes, and frameResize payloads
+// resize the underlying tmux window. It blocks until the client
+// disconnects or ctx is cancelled.
+func Serve(ctx context.Context, conn Conn, mgr session.Manager, id string) error {
+	if !mgr.Exists(id) {
+		return fmt.Errorf("attach: session %q does not exist", id)
+	}
+
+	logPath, err := mgr.StartLogging(id)
+	if err != nil {
+		return fmt.Errorf("attach: start logging: %w", err)
+	}
+
+	// Start tailing from the log's current size, not from byte zero —
+	// an attach should show new activity, not replay the session's
+	// entire history.
+	var offset int64
+	if info, err := os.Stat(logPath); err == nil {
+		offset = info.Size()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errc := make(chan error, 2)
+	go func() { errc <- pumpOutput(ctx, conn, mgr, id, offset) }()
+	go func() { errc <- pumpInput(ctx, conn, mgr, id) }()
+
+	err = <-errc
+	cancel()
+	<-errc // wait for the other pump to notice and exit
+	return err
+}
+
+// Conn is the subset of net.Conn Serve and the client side need; kept
+// narrow so tests can fake it without a real socket.
+type Conn interface {
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+}
+
+func pumpOutput(ctx context.Context, conn Conn, mgr session.Manager, id string, offset int64) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		data, newOffset, err := mgr.ReadLog(id, offset, 64*1024)
+		if err != nil {
+			return fmt.Errorf("attach: read log: %w", err)
+		}
+		if len(data) > 0 {
+			offset = newOffset
+			if err := writeFrame(conn, frameData, data); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func pumpInput(ctx context.Context, conn Conn, mgr session.Manager, id string) error {
+	type result struct {
+		typ     byte
+		payload []byte
+		err     error
+	}
+	frames := make(chan result)
+	go func() {
+		for {
+			typ, payload, err := readFrame(conn)
+			frames <- result{typ, payload, err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case f := <-frames:
+			if f.err != nil {
+				return f.err
+			}
+			switch f.typ {
+			case frameData:
+				if len(f.payload) == 0 {
+					continue
+				}
+				if err := mgr.SendText(id, string(f.payload), false); err != nil {
+					return fmt.Errorf("attach: send keys: %w", err)
+				}
+			case frameResize:
+				if rows, cols, ok := decodeResize(f.payload); ok {
+					mgr.Resize(id, int(rows), int(cols))
+				}
+			}
+		}
+	}
+}
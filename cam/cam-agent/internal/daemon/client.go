@@ -0,0 +1,179 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client talks to a running daemon over HTTP, either via a unix socket or
+// a TCP address. It exists so orchestrators can avoid the fork/exec cost
+// of a fresh cam-agent process per call.
+type Client struct {
+	base  string // e.g. "http://unix" or "https://host:port"
+	token string
+	http  *http.Client
+}
+
+// ClientOptions configures how to reach a daemon.
+type ClientOptions struct {
+	Addr     string // "host:port" for a TCP daemon
+	Unix     string // unix socket path; takes precedence over Addr
+	Token    string
+	TLS      bool
+	Insecure bool // skip TLS certificate verification
+}
+
+// NewClient returns a Client for the daemon described by opts.
+func NewClient(opts ClientOptions) (*Client, error) {
+	if opts.Unix == "" && opts.Addr == "" {
+		return nil, fmt.Errorf("daemon client: need --unix or --addr")
+	}
+
+	transport := &http.Transport{}
+	scheme := "http"
+	base := opts.Addr
+
+	if opts.Unix != "" {
+		sock := opts.Unix
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		}
+		base = "unix"
+	}
+
+	if opts.TLS {
+		scheme = "https"
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: opts.Insecure}
+	}
+
+	return &Client{
+		base:  scheme + "://" + base,
+		token: opts.Token,
+		http:  &http.Client{Transport: transport},
+	}, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body any) (*http.Request, error) {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+		// no body
+	case io.Reader:
+		// Raw passthrough (e.g. file/write streaming stdin) instead of
+		// JSON-encoding an opaque reader.
+		reader = b
+		contentType = "application/octet-stream"
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// Call issues a request for verb against the daemon and returns the raw
+// response body. query and body are optional; a non-nil body is sent with
+// POST (an io.Reader as-is, anything else JSON-encoded), otherwise the
+// request is a GET.
+func (c *Client) Call(ctx context.Context, verb string, query url.Values, body any) ([]byte, error) {
+	method := http.MethodGet
+	if body != nil {
+		method = http.MethodPost
+	}
+
+	req, err := c.newRequest(ctx, method, "/"+verb, query, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("daemon call %s: %w", verb, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("daemon call %s: read response: %w", verb, err)
+	}
+	if resp.StatusCode >= 400 {
+		var e errResponse
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return nil, fmt.Errorf("daemon call %s: %s", verb, e.Error)
+		}
+		return nil, fmt.Errorf("daemon call %s: status %d", verb, resp.StatusCode)
+	}
+	return data, nil
+}
+
+// Stream issues a streaming GET (session/capture or session/log-read with
+// ?stream=1) and invokes onChunk for every NDJSON line received until the
+// response ends or ctx is cancelled.
+func (c *Client) Stream(ctx context.Context, verb string, query url.Values, onChunk func(offset int64, data []byte)) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	if query.Get("stream") == "" {
+		query.Set("stream", "1")
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/"+verb, query, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("daemon stream %s: %w", verb, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("daemon stream %s: status %d: %s", verb, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimPrefix(scanner.Text(), "data: ")
+		if line == "" || !strings.HasPrefix(line, "{") {
+			continue // skip SSE "event:" / blank lines if the server sent SSE instead
+		}
+		var chunk logChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			continue
+		}
+		onChunk(chunk.Offset, chunk.Data)
+	}
+	return scanner.Err()
+}
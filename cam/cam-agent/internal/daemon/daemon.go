@@ -0,0 +1,504 @@
+// Package daemon exposes the agent's session and fileops verbs over
+// HTTP+JSON so orchestrators can talk to a long-lived process instead of
+// forking a new cam-agent for every call.
+package daemon
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/openclaw/cam-agent/internal/fileops"
+	"github.com/openclaw/cam-agent/internal/manifest"
+	"github.com/openclaw/cam-agent/internal/session"
+)
+
+// pollInterval is how often a streaming handler re-checks the log file
+// for new bytes once it has drained everything currently available.
+const pollInterval = 200 * time.Millisecond
+
+// Options configures the listeners and auth for Serve.
+type Options struct {
+	Listen  string // "host:port" for a TCP listener; empty to skip
+	Unix    string // unix socket path; empty to skip
+	Token   string // bearer token required on every request; empty disables auth
+	TLSCert string // PEM cert path; both Cert and Key must be set to enable TLS
+	TLSKey  string
+}
+
+// Server adapts a session.Manager and the fileops package to HTTP+JSON.
+type Server struct {
+	mgr   session.Manager
+	token string
+}
+
+// NewServer returns a Server backed by mgr. An empty token disables auth.
+func NewServer(mgr session.Manager, token string) *Server {
+	return &Server{mgr: mgr, token: token}
+}
+
+func (s *Server) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", s.handlePing)
+	mux.HandleFunc("/session/create", s.handleSessionCreate)
+	mux.HandleFunc("/session/apply", s.handleSessionApply)
+	mux.HandleFunc("/session/exists", s.handleSessionExists)
+	mux.HandleFunc("/session/kill", s.handleSessionKill)
+	mux.HandleFunc("/session/capture", s.handleSessionCapture)
+	mux.HandleFunc("/session/send", s.handleSessionSend)
+	mux.HandleFunc("/session/key", s.handleSessionKey)
+	mux.HandleFunc("/session/log-start", s.handleLogStart)
+	mux.HandleFunc("/session/log-read", s.handleLogRead)
+	mux.HandleFunc("/file/list", s.handleFileList)
+	mux.HandleFunc("/file/read", s.handleFileRead)
+	mux.HandleFunc("/file/write", s.handleFileWrite)
+	return mux
+}
+
+// Serve blocks, listening on whichever of opts.Listen / opts.Unix are set.
+// At least one of the two must be non-empty.
+func Serve(mgr session.Manager, opts Options) error {
+	if opts.Listen == "" && opts.Unix == "" {
+		return errors.New("daemon: need --listen and/or --unix")
+	}
+
+	srv := NewServer(mgr, opts.Token)
+	handler := srv.withAuth(srv.mux())
+
+	var tlsConfig *tls.Config
+	if opts.TLSCert != "" || opts.TLSKey != "" {
+		if opts.TLSCert == "" || opts.TLSKey == "" {
+			return errors.New("daemon: --tls-cert and --tls-key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.TLSCert, opts.TLSKey)
+		if err != nil {
+			return fmt.Errorf("daemon: load TLS keypair: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	listeners, err := listeners(opts)
+	if err != nil {
+		return err
+	}
+
+	errc := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		ln := ln
+		if tlsConfig != nil {
+			ln = tls.NewListener(ln, tlsConfig)
+		}
+		go func() {
+			errc <- (&http.Server{Handler: handler}).Serve(ln)
+		}()
+	}
+	return <-errc
+}
+
+func listeners(opts Options) ([]net.Listener, error) {
+	var out []net.Listener
+	if opts.Listen != "" {
+		ln, err := net.Listen("tcp", opts.Listen)
+		if err != nil {
+			return nil, fmt.Errorf("daemon: listen %s: %w", opts.Listen, err)
+		}
+		out = append(out, ln)
+	}
+	if opts.Unix != "" {
+		ln, err := net.Listen("unix", opts.Unix)
+		if err != nil {
+			return nil, fmt.Errorf("daemon: listen %s: %w", opts.Unix, err)
+		}
+		out = append(out, ln)
+	}
+	return out, nil
+}
+
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	want := "Bearer " + s.token
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// --- request/response envelopes ---
+
+type errResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errResponse{Error: err.Error()})
+}
+
+func decodeBody(r *http.Request, v any) error {
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode request body: %w", err)
+	}
+	return nil
+}
+
+// --- handlers ---
+
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+type sessionCreateReq struct {
+	ID       string   `json:"id"`
+	Workdir  string   `json:"workdir"`
+	EnvSetup string   `json:"env_setup"`
+	Cmd      []string `json:"cmd"`
+}
+
+func (s *Server) handleSessionCreate(w http.ResponseWriter, r *http.Request) {
+	var req sessionCreateReq
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ID == "" || req.Workdir == "" || len(req.Cmd) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("id, workdir and cmd are required"))
+		return
+	}
+	if err := s.mgr.Create(req.ID, req.Cmd, req.Workdir, req.EnvSetup); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+type sessionApplyReq struct {
+	Manifest string `json:"manifest"`
+}
+
+func (s *Server) handleSessionApply(w http.ResponseWriter, r *http.Request) {
+	var req sessionApplyReq
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	spec, err := manifest.Parse([]byte(req.Manifest))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.mgr.CreateLayout(spec.ID, spec); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true, "id": spec.ID})
+}
+
+func (s *Server) handleSessionExists(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errors.New("id is required"))
+		return
+	}
+	writeJSON(w, map[string]any{"exists": s.mgr.Exists(id)})
+}
+
+type idReq struct {
+	ID string `json:"id"`
+}
+
+func (s *Server) handleSessionKill(w http.ResponseWriter, r *http.Request) {
+	var req idReq
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.mgr.Kill(req.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+func (s *Server) handleSessionCapture(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	lines := queryInt(r, "lines", 100)
+	format := r.URL.Query().Get("format")
+	pane := r.URL.Query().Get("pane")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errors.New("id is required"))
+		return
+	}
+
+	if stream := r.URL.Query().Get("stream"); stream != "" {
+		s.streamLog(w, r, id, stream)
+		return
+	}
+
+	var out string
+	var err error
+	if pane != "" {
+		out, err = s.mgr.CapturePane(id, pane, lines)
+	} else {
+		out, err = s.mgr.CaptureFormatted(id, lines, format)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"output": out})
+}
+
+type sessionSendReq struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	NoEnter bool   `json:"no_enter"`
+	Pane    string `json:"pane"`
+}
+
+func (s *Server) handleSessionSend(w http.ResponseWriter, r *http.Request) {
+	var req sessionSendReq
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	pane := req.Pane
+	if pane == "" {
+		pane = "0.0"
+	}
+	if err := s.mgr.SendTextPane(req.ID, pane, req.Text, !req.NoEnter); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+type sessionKeyReq struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+func (s *Server) handleSessionKey(w http.ResponseWriter, r *http.Request) {
+	var req sessionKeyReq
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.mgr.SendKey(req.ID, req.Key); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+func (s *Server) handleLogStart(w http.ResponseWriter, r *http.Request) {
+	var req idReq
+	if err := decodeBody(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	path, err := s.mgr.StartLogging(req.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true, "path": path})
+}
+
+func (s *Server) handleLogRead(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errors.New("id is required"))
+		return
+	}
+
+	if stream := r.URL.Query().Get("stream"); stream != "" {
+		s.streamLog(w, r, id, stream)
+		return
+	}
+
+	offset := queryInt64(r, "offset", 0)
+	maxBytes := queryInt(r, "max_bytes", 256000)
+	data, newOffset, err := s.mgr.ReadLog(id, offset, maxBytes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"offset": newOffset, "data": data})
+}
+
+// streamLog tails the session's log file, pushing new bytes to the client
+// as tmux's pipe-pane writes them. format is either "sse" or anything else
+// (treated as NDJSON, the default for ?stream=1).
+func (s *Server) streamLog(w http.ResponseWriter, r *http.Request, id, format string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming unsupported"))
+		return
+	}
+
+	offset := queryInt64(r, "offset", 0)
+	sse := format == "sse"
+
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ctx := r.Context()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		data, newOffset, err := s.mgr.ReadLog(id, offset, 64*1024)
+		if err != nil {
+			s.writeStreamError(w, sse, err)
+			flusher.Flush()
+			return
+		}
+		if len(data) > 0 {
+			offset = newOffset
+			if err := s.writeStreamChunk(w, sse, offset, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type logChunk struct {
+	Offset int64  `json:"offset"`
+	Data   []byte `json:"data"` // base64-encoded by encoding/json
+}
+
+func (s *Server) writeStreamChunk(w http.ResponseWriter, sse bool, offset int64, data []byte) error {
+	payload, err := json.Marshal(logChunk{Offset: offset, Data: data})
+	if err != nil {
+		return err
+	}
+	if sse {
+		_, err = fmt.Fprintf(w, "data: %s\n\n", payload)
+	} else {
+		_, err = fmt.Fprintf(w, "%s\n", payload)
+	}
+	return err
+}
+
+func (s *Server) writeStreamError(w http.ResponseWriter, sse bool, err error) {
+	payload, _ := json.Marshal(errResponse{Error: err.Error()})
+	if sse {
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload)
+	} else {
+		fmt.Fprintf(w, "%s\n", payload)
+	}
+}
+
+type fileListResp struct {
+	Entries []fileops.FileEntry `json:"entries"`
+}
+
+func (s *Server) handleFileList(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeError(w, http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+	entries, err := fileops.List(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, fileListResp{Entries: entries})
+}
+
+func (s *Server) handleFileRead(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	maxBytes := queryInt(r, "max_bytes", 512000)
+	if path == "" {
+		writeError(w, http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+	data, err := fileops.Read(path, maxBytes)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+func (s *Server) handleFileWrite(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeError(w, http.StatusBadRequest, errors.New("path is required"))
+		return
+	}
+	buf := make([]byte, 0, 64*1024)
+	for {
+		chunk := make([]byte, 64*1024)
+		n, err := r.Body.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	if err := fileops.Write(path, buf); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+// --- query helpers ---
+
+func queryInt(r *http.Request, name string, def int) int {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	var n int
+	if _, err := fmt.Sscanf(v, "%d", &n); err != nil {
+		return def
+	}
+	return n
+}
+
+func queryInt64(r *http.Request, name string, def int64) int64 {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	var n int64
+	if _, err := fmt.Sscanf(v, "%d", &n); err != nil {
+		return def
+	}
+	return n
+}
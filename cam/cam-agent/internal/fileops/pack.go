@@ -0,0 +1,330 @@
+package fileops
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFile is the name of the per-directory exclude-pattern file,
+// checked in addition to whatever patterns the caller passes in.
+const ignoreFile = ".camignore"
+
+// PackOpts controls what Pack includes and how it compresses the stream.
+type PackOpts struct {
+	// Exclude holds extra glob patterns (matched against the path
+	// relative to root, same syntax as a .camignore line) to skip on
+	// top of any .camignore files found while walking.
+	Exclude []string
+	// Compression selects the archive's compression: "" (none) or
+	// "gzip". zstd is not implemented (no zstd encoder in the standard
+	// library and this repo has no vendored deps to add one); anything
+	// other than "" or "gzip" is rejected.
+	Compression string
+}
+
+// UnpackOpts controls how Unpack writes files back to disk.
+type UnpackOpts struct {
+	// Overwrite allows Unpack to replace files that already exist.
+	// Without it, an existing file or symlink is a hard error; existing
+	// directories are reused either way.
+	Overwrite bool
+	// MaxBytes caps the total decompressed bytes Unpack will write
+	// before it aborts. Zero means unlimited.
+	MaxBytes int64
+}
+
+// Pack walks root and streams its contents as a POSIX tar archive to w,
+// preserving mode, mtime and symlinks. Entries matching opts.Exclude or
+// any .camignore found along the way are skipped.
+func Pack(root string, opts PackOpts, w io.Writer) error {
+	root = filepath.Clean(root)
+
+	out := w
+	if opts.Compression == "gzip" {
+		gw := gzip.NewWriter(w)
+		defer gw.Close()
+		out = gw
+	} else if opts.Compression != "" {
+		return fmt.Errorf("pack: unsupported compression %q", opts.Compression)
+	}
+
+	tw := tar.NewWriter(out)
+	defer tw.Close()
+
+	// patternsByDir tracks, per directory, the exclude patterns in effect
+	// there: opts.Exclude plus every .camignore from root down to it. Since
+	// WalkDir visits a directory before its children, a child can always
+	// look its parent up here.
+	patternsByDir := map[string][]string{root: append([]string(nil), opts.Exclude...)}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		parent := filepath.Dir(path)
+		patterns := patternsByDir[parent]
+		if path == root {
+			patterns = patternsByDir[root]
+		}
+
+		if d.IsDir() {
+			if local, lerr := readIgnoreFile(path); lerr == nil {
+				patterns = append(append([]string(nil), patterns...), local...)
+			}
+			patternsByDir[path] = patterns
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if matchAny(patterns, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var linkTarget string
+		if info.Mode()&os.ModeSymlink != 0 {
+			linkTarget, err = os.Readlink(path)
+			if err != nil {
+				return fmt.Errorf("pack: readlink %s: %w", path, err)
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, linkTarget)
+		if err != nil {
+			return fmt.Errorf("pack: header for %s: %w", rel, err)
+		}
+		hdr.Name = rel
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("pack: write header for %s: %w", rel, err)
+		}
+
+		if info.Mode().IsRegular() {
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("pack: open %s: %w", path, err)
+			}
+			_, err = io.Copy(tw, f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("pack: write %s: %w", rel, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Unpack reads a POSIX tar archive from r (gzip-compressed archives are
+// detected automatically) and extracts it under root, rejecting any entry
+// whose path or symlink target would escape root.
+func Unpack(root string, r io.Reader, opts UnpackOpts) error {
+	root = filepath.Clean(root)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return fmt.Errorf("unpack: create root %s: %w", root, err)
+	}
+
+	src, err := maybeGunzip(r)
+	if err != nil {
+		return err
+	}
+	if opts.MaxBytes > 0 {
+		src = &limitedReader{r: src, remaining: opts.MaxBytes}
+	}
+
+	tr := tar.NewReader(src)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unpack: read header: %w", err)
+		}
+
+		dest, err := safeJoin(root, hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dest, os.FileMode(hdr.Mode)); err != nil {
+				return fmt.Errorf("unpack: mkdir %s: %w", hdr.Name, err)
+			}
+		case tar.TypeSymlink:
+			if err := checkSymlinkTarget(root, dest, hdr.Linkname); err != nil {
+				return err
+			}
+			if err := extractSymlink(dest, hdr.Linkname, opts.Overwrite); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(dest, hdr, tr, opts.Overwrite); err != nil {
+				return err
+			}
+		default:
+			// Skip device nodes, fifos, etc. — not meaningful for a
+			// workdir sync and not safe to recreate unprivileged.
+		}
+	}
+}
+
+func extractFile(dest string, hdr *tar.Header, r io.Reader, overwrite bool) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("unpack: mkdir %s: %w", filepath.Dir(dest), err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(dest, flags, os.FileMode(hdr.Mode))
+	if err != nil {
+		if !overwrite && os.IsExist(err) {
+			return fmt.Errorf("unpack: %s already exists (use --overwrite)", hdr.Name)
+		}
+		return fmt.Errorf("unpack: create %s: %w", hdr.Name, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("unpack: write %s: %w", hdr.Name, err)
+	}
+	return os.Chtimes(dest, hdr.ModTime, hdr.ModTime)
+}
+
+func extractSymlink(dest, linkname string, overwrite bool) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("unpack: mkdir %s: %w", filepath.Dir(dest), err)
+	}
+	if overwrite {
+		os.Remove(dest)
+	}
+	if err := os.Symlink(linkname, dest); err != nil {
+		if !overwrite && os.IsExist(err) {
+			return fmt.Errorf("unpack: %s already exists (use --overwrite)", dest)
+		}
+		return fmt.Errorf("unpack: symlink %s -> %s: %w", dest, linkname, err)
+	}
+	return nil
+}
+
+// safeJoin resolves name against root and rejects any entry whose
+// cleaned path would land outside of it (a classic "zip slip" guard).
+func safeJoin(root, name string) (string, error) {
+	joined := filepath.Join(root, name) // Join cleans, so ".." can climb out
+	if joined != root && !strings.HasPrefix(joined, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("unpack: entry %q escapes root", name)
+	}
+	return joined, nil
+}
+
+// checkSymlinkTarget rejects absolute symlink targets, and relative ones
+// that resolve outside root.
+func checkSymlinkTarget(root, dest, linkname string) error {
+	if filepath.IsAbs(linkname) {
+		return fmt.Errorf("unpack: symlink %s has absolute target %q", dest, linkname)
+	}
+	resolved := filepath.Clean(filepath.Join(filepath.Dir(dest), linkname))
+	if resolved != root && !strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+		return fmt.Errorf("unpack: symlink %s target %q escapes root", dest, linkname)
+	}
+	return nil
+}
+
+func maybeGunzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("unpack: peek stream: %w", err)
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("unpack: gzip: %w", err)
+		}
+		return gr, nil
+	}
+	return br, nil
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		return 0, errors.New("unpack: max-bytes limit exceeded")
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// readIgnoreFile reads dir/.camignore, returning one glob pattern per
+// non-empty, non-comment line, unmodified. matchAny applies each pattern
+// against both the walked entry's path relative to root and its base
+// name, so a pattern from a .camignore found partway down the tree still
+// only takes effect at or below that directory (patternsByDir only
+// carries it into dir's own subtree), without needing the pattern string
+// itself to be rewritten.
+func readIgnoreFile(dir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ignoreFile))
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// matchAny reports whether rel, or its base name, matches one of the
+// given glob patterns. Other path components are not matched on their own.
+func matchAny(patterns []string, rel string) bool {
+	base := filepath.Base(rel)
+	for _, pat := range patterns {
+		pat = strings.TrimSuffix(pat, "/")
+		if ok, _ := filepath.Match(pat, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pat, base); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+// Package manifest loads declarative session manifests — YAML files
+// describing a session's windows and panes — for cam-agent session
+// apply, decoding them into a session.LayoutSpec for Manager.CreateLayout.
+//
+// A manifest looks like:
+//
+//	id: dev
+//	workdir: /srv/app
+//	env_setup: source .venv/bin/activate
+//	windows:
+//	  - name: editor
+//	    layout: main-vertical
+//	    panes:
+//	      - command: vim
+//	      - command: npm run dev
+//	        send_keys:
+//	          - clear
+//	  - name: logs
+//	    layout: tiled
+//	    panes:
+//	      - command: tail -f log/app.log
+//	        cwd: /srv/app/log
+package manifest
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openclaw/cam-agent/internal/session"
+)
+
+// Load reads and decodes the manifest at path into a LayoutSpec.
+func Load(path string) (session.LayoutSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return session.LayoutSpec{}, fmt.Errorf("manifest: read %s: %w", path, err)
+	}
+
+	spec, err := Parse(data)
+	if err != nil {
+		return session.LayoutSpec{}, fmt.Errorf("manifest: %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Parse decodes manifest YAML already read into memory, e.g. a request
+// body received by the daemon rather than a local file.
+func Parse(data []byte) (session.LayoutSpec, error) {
+	tree, err := parseYAML(data)
+	if err != nil {
+		return session.LayoutSpec{}, fmt.Errorf("manifest: parse: %w", err)
+	}
+	return decodeSpec(tree)
+}
+
+func decodeSpec(tree any) (session.LayoutSpec, error) {
+	root, ok := tree.(map[string]any)
+	if !ok {
+		return session.LayoutSpec{}, fmt.Errorf("top level must be a mapping")
+	}
+
+	spec := session.LayoutSpec{
+		ID:       str(root["id"]),
+		Workdir:  str(root["workdir"]),
+		EnvSetup: str(root["env_setup"]),
+	}
+	if spec.ID == "" {
+		return session.LayoutSpec{}, fmt.Errorf("missing required field %q", "id")
+	}
+
+	rawWindows, _ := root["windows"].([]any)
+	if len(rawWindows) == 0 {
+		return session.LayoutSpec{}, fmt.Errorf("%q must be a non-empty list", "windows")
+	}
+
+	for wi, rw := range rawWindows {
+		win, err := decodeWindow(rw)
+		if err != nil {
+			return session.LayoutSpec{}, fmt.Errorf("windows[%d]: %w", wi, err)
+		}
+		spec.Windows = append(spec.Windows, win)
+	}
+
+	return spec, nil
+}
+
+func decodeWindow(rw any) (session.WindowSpec, error) {
+	winMap, ok := rw.(map[string]any)
+	if !ok {
+		return session.WindowSpec{}, fmt.Errorf("must be a mapping")
+	}
+
+	win := session.WindowSpec{
+		Name:   str(winMap["name"]),
+		Layout: str(winMap["layout"]),
+	}
+
+	rawPanes, _ := winMap["panes"].([]any)
+	if len(rawPanes) == 0 {
+		return session.WindowSpec{}, fmt.Errorf("%q must be a non-empty list", "panes")
+	}
+
+	for pi, rp := range rawPanes {
+		pane, err := decodePane(rp)
+		if err != nil {
+			return session.WindowSpec{}, fmt.Errorf("panes[%d]: %w", pi, err)
+		}
+		win.Panes = append(win.Panes, pane)
+	}
+
+	return win, nil
+}
+
+func decodePane(rp any) (session.PaneSpec, error) {
+	paneMap, ok := rp.(map[string]any)
+	if !ok {
+		return session.PaneSpec{}, fmt.Errorf("must be a mapping")
+	}
+
+	pane := session.PaneSpec{
+		Command: str(paneMap["command"]),
+		Cwd:     str(paneMap["cwd"]),
+	}
+	if pane.Command == "" {
+		return session.PaneSpec{}, fmt.Errorf("missing required field %q", "command")
+	}
+
+	if rawKeys, ok := paneMap["send_keys"].([]any); ok {
+		for _, k := range rawKeys {
+			pane.SendKeys = append(pane.SendKeys, str(k))
+		}
+	}
+
+	return pane, nil
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
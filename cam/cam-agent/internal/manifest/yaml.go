@@ -0,0 +1,222 @@
+package manifest
+
+// yaml.go implements just enough of YAML to read the session manifests
+// this package decodes: nested mappings, "- " sequences of mappings or
+// scalars, and scalar strings (bare or quoted). It is not a general YAML
+// parser — anchors, multi-line scalars, flow style ({}, […]) and most of
+// the rest of the spec are unsupported. Over-indented lines, such as a
+// multi-line scalar's continuation, are a parse error; flow-style values
+// and anchors are not recognized and are read as plain string scalars.
+
+import (
+	"fmt"
+	"strings"
+)
+
+type yamlLine struct {
+	indent int
+	text   string
+}
+
+// tokenizeYAML strips comments and blank lines and records each
+// remaining line's leading-space indent.
+func tokenizeYAML(data []byte) []yamlLine {
+	var lines []yamlLine
+	for _, raw := range strings.Split(string(data), "\n") {
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		lines = append(lines, yamlLine{indent: indent, text: strings.TrimLeft(line, " ")})
+	}
+	return lines
+}
+
+// stripComment drops a trailing "# ..." comment, ignoring '#' inside a
+// quoted value.
+func stripComment(line string) string {
+	inSingle, inDouble := false, false
+	for i, r := range line {
+		switch r {
+		case '\'':
+			if !inDouble {
+				inSingle = !inSingle
+			}
+		case '"':
+			if !inSingle {
+				inDouble = !inDouble
+			}
+		case '#':
+			if !inSingle && !inDouble && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseYAML parses a restricted YAML document into a tree of
+// map[string]any, []any and string values.
+func parseYAML(data []byte) (any, error) {
+	lines := tokenizeYAML(data)
+	val, rest, err := parseBlock(lines)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("yaml: unexpected indentation near %q", rest[0].text)
+	}
+	if val == nil {
+		val = map[string]any{}
+	}
+	return val, nil
+}
+
+// parseBlock parses the sibling lines at lines[0]'s indent — as a
+// sequence if they start with "- ", otherwise as a mapping — stopping
+// when indentation drops below that level or the input is exhausted.
+func parseBlock(lines []yamlLine) (any, []yamlLine, error) {
+	if len(lines) == 0 {
+		return nil, lines, nil
+	}
+	indent := lines[0].indent
+	if isSeqItem(lines[0].text) {
+		return parseSequence(lines, indent)
+	}
+	return parseMapping(lines, indent)
+}
+
+func parseMapping(lines []yamlLine, indent int) (any, []yamlLine, error) {
+	m := map[string]any{}
+	for len(lines) > 0 && lines[0].indent == indent && !isSeqItem(lines[0].text) {
+		key, value, ok := splitKeyValue(lines[0].text)
+		if !ok {
+			return nil, nil, fmt.Errorf("yaml: expected \"key: value\", got %q", lines[0].text)
+		}
+		rest := lines[1:]
+
+		if value != "" {
+			m[key] = parseScalar(value)
+			lines = rest
+			continue
+		}
+
+		if len(rest) > 0 && rest[0].indent > indent {
+			nested, r, err := parseBlock(rest)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key] = nested
+			lines = r
+			continue
+		}
+
+		m[key] = nil
+		lines = rest
+	}
+	return m, lines, nil
+}
+
+func parseSequence(lines []yamlLine, indent int) (any, []yamlLine, error) {
+	var seq []any
+	for len(lines) > 0 && lines[0].indent == indent && isSeqItem(lines[0].text) {
+		item := strings.TrimSpace(strings.TrimPrefix(lines[0].text, "-"))
+		rest := lines[1:]
+
+		// Everything indented past indent, following the "- " line,
+		// belongs to this item, not the next one.
+		var children []yamlLine
+		for len(rest) > 0 && rest[0].indent > indent {
+			children = append(children, rest[0])
+			rest = rest[1:]
+		}
+
+		switch {
+		case item == "":
+			val, remaining, err := parseBlock(children)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(remaining) != 0 {
+				return nil, nil, fmt.Errorf("yaml: malformed sequence item near %q", remaining[0].text)
+			}
+			seq = append(seq, val)
+		default:
+			if isMappingItem(item) {
+				// "- key: value" starts a mapping; the item's own line
+				// plus any deeper-indented lines that follow make up
+				// its fields.
+				itemLines := append([]yamlLine{{indent: indent + 2, text: item}}, children...)
+				val, remaining, err := parseBlock(itemLines)
+				if err != nil {
+					return nil, nil, err
+				}
+				if len(remaining) != 0 {
+					return nil, nil, fmt.Errorf("yaml: malformed sequence item near %q", remaining[0].text)
+				}
+				seq = append(seq, val)
+			} else {
+				// A bare scalar item, e.g. a send_keys entry.
+				if len(children) != 0 {
+					return nil, nil, fmt.Errorf("yaml: unexpected indentation after %q", item)
+				}
+				seq = append(seq, parseScalar(item))
+			}
+		}
+		lines = rest
+	}
+	return seq, lines, nil
+}
+
+// isMappingItem reports whether a sequence item's raw text ("- " already
+// stripped) is genuinely "key: value" shaped, as opposed to a bare
+// scalar that merely happens to contain a colon (a send_keys line like
+// "echo http://example.com", or a quoted ":wq"). A quoted scalar is
+// never a mapping, and an unquoted one only counts if the first colon is
+// followed by a space or end-of-line, the way a real "key:" is written.
+func isMappingItem(item string) bool {
+	if isQuotedScalar(item) {
+		return false
+	}
+	idx := strings.Index(item, ":")
+	if idx < 0 {
+		return false
+	}
+	if strings.TrimSpace(item[:idx]) == "" {
+		return false
+	}
+	return idx+1 == len(item) || item[idx+1] == ' '
+}
+
+// isQuotedScalar reports whether s is wrapped in a single matching pair
+// of single or double quotes.
+func isQuotedScalar(s string) bool {
+	return len(s) >= 2 && ((s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\''))
+}
+
+// splitKeyValue splits "key: value" on the first colon; a trailing bare
+// "key:" returns an empty value, signaling the value lives on following,
+// more-indented lines.
+func splitKeyValue(text string) (key, value string, ok bool) {
+	idx := strings.Index(text, ":")
+	if idx < 0 {
+		return "", "", false
+	}
+	key = strings.TrimSpace(text[:idx])
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[idx+1:]), true
+}
+
+func parseScalar(s string) string {
+	if isQuotedScalar(s) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,285 @@
+// Package ninep implements a minimal classic 9P2000 file server (not
+// 9P2000.L) sufficient for a Linux controller to `mount -t 9p` a
+// cam-agent workdir and browse/edit it with normal POSIX calls, instead
+// of round-tripping every read/write through the JSON fileops verbs.
+//
+// Only the core message set needed for that workflow is implemented:
+// version negotiation, attach, walk, open, create, read, write, remove,
+// stat and clunk. Wstat, auth and flush are intentionally out of scope.
+//
+// Because the Linux kernel's 9p client defaults to negotiating
+// 9P2000.L, mounting against this server requires explicitly requesting
+// the classic protocol:
+//
+//	mount -t 9p -o trans=unix,version=9p2000,uname=root <socket> /mnt
+//
+// Omitting version=9p2000 makes the client propose "9p2000.L", which
+// this server answers with "unknown" per Tversion semantics, and the
+// mount fails with -EREMOTEIO.
+package ninep
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Message types. Odd numbers are the matching R-message for the T-message
+// one below it (Tversion=100 -> Rversion=101, etc).
+const (
+	msgTversion = 100
+	msgRversion = 101
+	msgTattach  = 104
+	msgRattach  = 105
+	msgRerror   = 107
+	msgTwalk    = 110
+	msgRwalk    = 111
+	msgTopen    = 112
+	msgRopen    = 113
+	msgTcreate  = 114
+	msgRcreate  = 115
+	msgTread    = 116
+	msgRread    = 117
+	msgTwrite   = 118
+	msgRwrite   = 119
+	msgTclunk   = 120
+	msgRclunk   = 121
+	msgTremove  = 122
+	msgRremove  = 123
+	msgTstat    = 124
+	msgRstat    = 125
+)
+
+// NoFid/NoTag are the 9P "unset" sentinels.
+const (
+	NoFid uint32 = 0xFFFFFFFF
+	NoTag uint16 = 0xFFFF
+)
+
+// Version is the only protocol version this server negotiates.
+const Version = "9P2000"
+
+// Qid type bits (the high byte of a qid's path-independent type field).
+const (
+	QTDIR    = 0x80
+	QTAPPEND = 0x40
+	QTEXCL   = 0x20
+	QTTMP    = 0x04
+	QTFILE   = 0x00
+)
+
+// Open/create mode bits (the low bits of Topen/Tcreate's mode byte).
+const (
+	OREAD  = 0
+	OWRITE = 1
+	ORDWR  = 2
+	OTRUNC = 0x10
+)
+
+// DMDIR marks a Perm value (used in Tcreate and Stat) as a directory.
+const DMDIR = 0x80000000
+
+// Qid uniquely identifies a file on the wire: a type byte, a version
+// counter, and a 64-bit path (we use the inode-ish path as a stable id).
+type Qid struct {
+	Type    uint8
+	Version uint32
+	Path    uint64
+}
+
+func (q Qid) encode(e *encoder) {
+	e.u8(q.Type)
+	e.u32(q.Version)
+	e.u64(q.Path)
+}
+
+func decodeQid(d *decoder) Qid {
+	return Qid{Type: d.u8(), Version: d.u32(), Path: d.u64()}
+}
+
+// Stat mirrors the 9P2000 stat structure closely enough for our purposes
+// (the kernel fields irrelevant to a single-user export — dev, uid/gid
+// strings — are filled with fixed placeholders).
+type Stat struct {
+	Qid    Qid
+	Mode   uint32 // permission bits, DMDIR for directories
+	Mtime  uint32
+	Length uint64
+	Name   string
+	UID    string
+	GID    string
+}
+
+func (s Stat) encode(e *encoder) {
+	// Stat entries are wrapped in their own 2-byte size prefix (the size
+	// of everything that follows), as required by Twstat/Rstat/Rread-of-
+	// a-directory.
+	body := newEncoder()
+	body.u16(0) // kernel "type", unused
+	body.u32(0) // kernel "dev", unused
+	s.Qid.encode(body)
+	body.u32(s.Mode)
+	body.u32(0) // atime, unused
+	body.u32(s.Mtime)
+	body.u64(s.Length)
+	body.str(s.Name)
+	body.str(s.UID)
+	body.str(s.GID)
+	body.str(s.UID) // muid: who last modified it; we don't track this separately
+	e.u16(uint16(len(body.buf)))
+	e.bytes(body.buf)
+}
+
+// --- wire-level messages ---
+
+type message struct {
+	typ uint8
+	tag uint16
+	// body holds the already-encoded, type-specific fields (everything
+	// after the 7-byte size/type/tag header).
+	body []byte
+}
+
+func readMessage(r io.Reader, maxSize uint32) (*message, error) {
+	var hdr [7]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(hdr[0:4])
+	if size < 7 {
+		return nil, fmt.Errorf("ninep: message too small (%d bytes)", size)
+	}
+	if maxSize != 0 && size > maxSize {
+		return nil, fmt.Errorf("ninep: message exceeds msize (%d > %d)", size, maxSize)
+	}
+	body := make([]byte, size-7)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &message{
+		typ:  hdr[4],
+		tag:  binary.LittleEndian.Uint16(hdr[5:7]),
+		body: body,
+	}, nil
+}
+
+func writeMessage(w io.Writer, typ uint8, tag uint16, body []byte) error {
+	size := 7 + len(body)
+	out := make([]byte, size)
+	binary.LittleEndian.PutUint32(out[0:4], uint32(size))
+	out[4] = typ
+	binary.LittleEndian.PutUint16(out[5:7], tag)
+	copy(out[7:], body)
+	_, err := w.Write(out)
+	return err
+}
+
+func writeError(w io.Writer, tag uint16, err error) error {
+	e := newEncoder()
+	e.str(err.Error())
+	return writeMessage(w, msgRerror, tag, e.buf)
+}
+
+// --- little-endian encode/decode helpers ---
+
+type encoder struct{ buf []byte }
+
+func newEncoder() *encoder { return &encoder{} }
+
+func (e *encoder) u8(v uint8) { e.buf = append(e.buf, v) }
+func (e *encoder) u16(v uint16) {
+	e.buf = append(e.buf, byte(v), byte(v>>8))
+}
+func (e *encoder) u32(v uint32) {
+	e.buf = append(e.buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+func (e *encoder) u64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+}
+func (e *encoder) str(s string) {
+	e.u16(uint16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+func (e *encoder) bytes(b []byte) { e.buf = append(e.buf, b...) }
+
+type decoder struct {
+	buf []byte
+	off int
+	err error
+}
+
+func newDecoder(b []byte) *decoder { return &decoder{buf: b} }
+
+func (d *decoder) need(n int) bool {
+	if d.err != nil || d.off+n > len(d.buf) {
+		if d.err == nil {
+			d.err = errors.New("ninep: short message")
+		}
+		return false
+	}
+	return true
+}
+
+func (d *decoder) u8() uint8 {
+	if !d.need(1) {
+		return 0
+	}
+	v := d.buf[d.off]
+	d.off++
+	return v
+}
+
+func (d *decoder) u16() uint16 {
+	if !d.need(2) {
+		return 0
+	}
+	v := binary.LittleEndian.Uint16(d.buf[d.off:])
+	d.off += 2
+	return v
+}
+
+func (d *decoder) u32() uint32 {
+	if !d.need(4) {
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(d.buf[d.off:])
+	d.off += 4
+	return v
+}
+
+func (d *decoder) u64() uint64 {
+	if !d.need(8) {
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(d.buf[d.off:])
+	d.off += 8
+	return v
+}
+
+func (d *decoder) str() string {
+	n := int(d.u16())
+	if !d.need(n) {
+		return ""
+	}
+	s := string(d.buf[d.off : d.off+n])
+	d.off += n
+	return s
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if !d.need(n) {
+		return nil
+	}
+	b := d.buf[d.off : d.off+n]
+	d.off += n
+	return b
+}
+
+func (d *decoder) rest() []byte {
+	b := d.buf[d.off:]
+	d.off = len(d.buf)
+	return b
+}
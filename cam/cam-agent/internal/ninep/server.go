@@ -0,0 +1,713 @@
+package ninep
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Options configures a Server.
+type Options struct {
+	Root     string // directory jailed at the export's root ("/" on the wire)
+	ReadOnly bool   // reject Topen/Tcreate/Tremove/Twrite with write intent
+	// Secret, if set, must be supplied as the aname of every Tattach
+	// (a Linux client passes it with mount -o aname=...); attaches that don't
+	// match are refused. Empty disables the check.
+	Secret string
+}
+
+// Server exports Options.Root over 9P2000 to any number of connections.
+type Server struct {
+	opts    Options
+	root    string
+	pathIDs sync.Map // absolute path (string) -> qid path (uint64)
+	nextID  atomic.Uint64
+}
+
+// NewServer validates opts and returns a Server ready for Serve.
+func NewServer(opts Options) (*Server, error) {
+	root, err := filepath.Abs(opts.Root)
+	if err != nil {
+		return nil, fmt.Errorf("ninep: resolve root: %w", err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("ninep: root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("ninep: root %s is not a directory", root)
+	}
+	return &Server{opts: opts, root: root}, nil
+}
+
+// ParseListen splits a "unix:/path" or "tcp:host:port" spec into the
+// (network, address) pair net.Listen expects.
+func ParseListen(spec string) (network, address string, err error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("ninep: invalid --listen %q (want unix:/path or tcp:host:port)", spec)
+	}
+	switch parts[0] {
+	case "unix":
+		return "unix", parts[1], nil
+	case "tcp":
+		return "tcp", parts[1], nil
+	default:
+		return "", "", fmt.Errorf("ninep: unknown listen network %q", parts[0])
+	}
+}
+
+// Serve accepts connections on ln and handles each on its own goroutine
+// until ln is closed or Accept returns a fatal error.
+func (s *Server) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// qidPathFor returns a stable qid path for an absolute filesystem path,
+// allocating a new one on first sight.
+func (s *Server) qidPathFor(abs string) uint64 {
+	if v, ok := s.pathIDs.Load(abs); ok {
+		return v.(uint64)
+	}
+	id := s.nextID.Add(1)
+	actual, _ := s.pathIDs.LoadOrStore(abs, id)
+	return actual.(uint64)
+}
+
+func (s *Server) qidFor(abs string, info os.FileInfo) Qid {
+	var typ uint8
+	if info.IsDir() {
+		typ = QTDIR
+	}
+	return Qid{Type: typ, Version: uint32(info.ModTime().Unix()), Path: s.qidPathFor(abs)}
+}
+
+// fid tracks one client-allocated handle: where it points in the tree and
+// (once opened) the backing *os.File or cached directory listing.
+type fid struct {
+	rel    string // path relative to root; "" is the root itself
+	file   *os.File
+	dirBuf []byte // pre-encoded Stat entries, valid once opened as a dir
+	isDir  bool
+	opened bool
+}
+
+type conn struct {
+	srv      *Server
+	nc       net.Conn
+	msize    uint32
+	mu       sync.Mutex
+	fids     map[uint32]*fid
+	attached bool
+}
+
+func (s *Server) handleConn(nc net.Conn) {
+	defer nc.Close()
+	c := &conn{srv: s, nc: nc, msize: 64 * 1024, fids: make(map[uint32]*fid)}
+	for {
+		msg, err := readMessage(nc, 1<<20)
+		if err != nil {
+			return
+		}
+		resp, rtag, err := c.dispatch(msg)
+		if err != nil {
+			writeError(nc, msg.tag, err)
+			continue
+		}
+		if writeMessage(nc, resp.typ(), rtag, resp.encode()) != nil {
+			return
+		}
+	}
+}
+
+// reply is implemented by every R-message body so dispatch can stay
+// generic about what it's sending back.
+type reply interface {
+	typ() uint8
+	encode() []byte
+}
+
+func (c *conn) dispatch(msg *message) (reply, uint16, error) {
+	d := newDecoder(msg.body)
+	switch msg.typ {
+	case msgTversion:
+		r, err := c.tversion(d)
+		return r, msg.tag, err
+	case msgTattach:
+		r, err := c.tattach(d)
+		return r, msg.tag, err
+	case msgTwalk:
+		r, err := c.twalk(d)
+		return r, msg.tag, err
+	case msgTopen:
+		r, err := c.topen(d)
+		return r, msg.tag, err
+	case msgTcreate:
+		r, err := c.tcreate(d)
+		return r, msg.tag, err
+	case msgTread:
+		r, err := c.tread(d)
+		return r, msg.tag, err
+	case msgTwrite:
+		r, err := c.twrite(d)
+		return r, msg.tag, err
+	case msgTclunk:
+		r, err := c.tclunk(d)
+		return r, msg.tag, err
+	case msgTremove:
+		r, err := c.tremove(d)
+		return r, msg.tag, err
+	case msgTstat:
+		r, err := c.tstat(d)
+		return r, msg.tag, err
+	default:
+		return nil, msg.tag, fmt.Errorf("ninep: unsupported message type %d", msg.typ)
+	}
+}
+
+// --- per-message handlers ---
+
+type rversion struct {
+	msize uint32
+	ver   string
+}
+
+func (r rversion) typ() uint8 { return msgRversion }
+func (r rversion) encode() []byte {
+	e := newEncoder()
+	e.u32(r.msize)
+	e.str(r.ver)
+	return e.buf
+}
+
+func (c *conn) tversion(d *decoder) (reply, error) {
+	msize := d.u32()
+	ver := d.str()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if ver != Version {
+		ver = "unknown"
+	} else if msize < 256 {
+		return nil, errors.New("ninep: msize too small")
+	} else {
+		c.msize = msize
+	}
+	return rversion{msize: c.msize, ver: ver}, nil
+}
+
+type rattach struct{ qid Qid }
+
+func (r rattach) typ() uint8 { return msgRattach }
+func (r rattach) encode() []byte {
+	e := newEncoder()
+	r.qid.encode(e)
+	return e.buf
+}
+
+func (c *conn) tattach(d *decoder) (reply, error) {
+	newFid := d.u32()
+	_ = d.u32() // afid, unused (no auth)
+	_ = d.str() // uname, unused
+	aname := d.str()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if c.srv.opts.Secret != "" {
+		if subtle.ConstantTimeCompare([]byte(aname), []byte(c.srv.opts.Secret)) != 1 {
+			return nil, errors.New("ninep: attach refused: bad secret")
+		}
+	}
+
+	info, err := os.Stat(c.srv.root)
+	if err != nil {
+		return nil, err
+	}
+	qid := c.srv.qidFor(c.srv.root, info)
+
+	c.mu.Lock()
+	c.fids[newFid] = &fid{rel: ""}
+	c.attached = true
+	c.mu.Unlock()
+
+	return rattach{qid: qid}, nil
+}
+
+type rwalk struct{ qids []Qid }
+
+func (r rwalk) typ() uint8 { return msgRwalk }
+func (r rwalk) encode() []byte {
+	e := newEncoder()
+	e.u16(uint16(len(r.qids)))
+	for _, q := range r.qids {
+		q.encode(e)
+	}
+	return e.buf
+}
+
+func (c *conn) twalk(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	newFidNum := d.u32()
+	nwname := d.u16()
+	names := make([]string, nwname)
+	for i := range names {
+		names[i] = d.str()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	c.mu.Lock()
+	base, ok := c.fids[fidNum]
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ninep: unknown fid %d", fidNum)
+	}
+
+	rel := base.rel
+	var qids []Qid
+	for _, name := range names {
+		next, err := joinRel(rel, name)
+		if err != nil {
+			break // partial walk: stop and return what matched so far
+		}
+		info, err := os.Lstat(filepath.Join(c.srv.root, next))
+		if err != nil {
+			break
+		}
+		qids = append(qids, c.srv.qidFor(filepath.Join(c.srv.root, next), info))
+		rel = next
+	}
+
+	// A partial walk (fewer qids than requested names, with names
+	// non-empty) is not an error at the protocol level; the client
+	// treats a short qid list as "walk stopped here". A full walk (or a
+	// zero-length walk used to clone a fid) installs newfid.
+	if len(qids) == len(names) {
+		c.mu.Lock()
+		c.fids[newFidNum] = &fid{rel: rel}
+		c.mu.Unlock()
+	}
+
+	return rwalk{qids: qids}, nil
+}
+
+type ropen struct {
+	qid    Qid
+	iounit uint32
+}
+
+func (r ropen) typ() uint8 { return msgRopen }
+func (r ropen) encode() []byte {
+	e := newEncoder()
+	r.qid.encode(e)
+	e.u32(r.iounit)
+	return e.buf
+}
+
+func (c *conn) topen(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	mode := d.u8()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	c.mu.Lock()
+	f, ok := c.fids[fidNum]
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ninep: unknown fid %d", fidNum)
+	}
+
+	abs, err := resolveJailed(c.srv.root, filepath.Join(c.srv.root, f.rel))
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		entries, err := os.ReadDir(abs)
+		if err != nil {
+			return nil, err
+		}
+		f.dirBuf = encodeDirStats(c.srv, abs, entries)
+		f.isDir = true
+		f.opened = true
+		return ropen{qid: c.srv.qidFor(abs, info), iounit: c.msize - 24}, nil
+	}
+
+	if c.srv.opts.ReadOnly && (mode&3 != OREAD || mode&OTRUNC != 0) {
+		return nil, errors.New("ninep: export is read-only")
+	}
+
+	osFlags := os.O_RDONLY
+	switch mode & 3 {
+	case OWRITE:
+		osFlags = os.O_WRONLY
+	case ORDWR:
+		osFlags = os.O_RDWR
+	}
+	if mode&OTRUNC != 0 {
+		osFlags |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(abs, osFlags, 0)
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+	f.opened = true
+	return ropen{qid: c.srv.qidFor(abs, info), iounit: c.msize - 24}, nil
+}
+
+type rcreate struct {
+	qid    Qid
+	iounit uint32
+}
+
+func (r rcreate) typ() uint8 { return msgRcreate }
+func (r rcreate) encode() []byte {
+	e := newEncoder()
+	r.qid.encode(e)
+	e.u32(r.iounit)
+	return e.buf
+}
+
+func (c *conn) tcreate(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	name := d.str()
+	perm := d.u32()
+	mode := d.u8()
+	if d.err != nil {
+		return nil, d.err
+	}
+	if c.srv.opts.ReadOnly {
+		return nil, errors.New("ninep: export is read-only")
+	}
+
+	c.mu.Lock()
+	f, ok := c.fids[fidNum]
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ninep: unknown fid %d", fidNum)
+	}
+
+	rel, err := joinRel(f.rel, name)
+	if err != nil {
+		return nil, err
+	}
+	parentAbs, err := resolveJailed(c.srv.root, filepath.Join(c.srv.root, f.rel))
+	if err != nil {
+		return nil, err
+	}
+	abs := filepath.Join(parentAbs, name)
+
+	if perm&DMDIR != 0 {
+		if err := os.Mkdir(abs, os.FileMode(perm&0777)); err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, err
+		}
+		entries, _ := os.ReadDir(abs)
+		f.rel = rel
+		f.isDir = true
+		f.opened = true
+		f.dirBuf = encodeDirStats(c.srv, abs, entries)
+		return rcreate{qid: c.srv.qidFor(abs, info), iounit: c.msize - 24}, nil
+	}
+
+	file, err := os.OpenFile(abs, os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.FileMode(perm&0777))
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.rel = rel
+	f.file = file
+	f.opened = true
+	_ = mode
+	return rcreate{qid: c.srv.qidFor(abs, info), iounit: c.msize - 24}, nil
+}
+
+type rread struct{ data []byte }
+
+func (r rread) typ() uint8 { return msgRread }
+func (r rread) encode() []byte {
+	e := newEncoder()
+	e.u32(uint32(len(r.data)))
+	e.bytes(r.data)
+	return e.buf
+}
+
+func (c *conn) tread(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	offset := d.u64()
+	count := d.u32()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	c.mu.Lock()
+	f, ok := c.fids[fidNum]
+	c.mu.Unlock()
+	if !ok || !f.opened {
+		return nil, fmt.Errorf("ninep: fid %d not open", fidNum)
+	}
+
+	if f.isDir {
+		return rread{data: sliceAt(f.dirBuf, offset, count)}, nil
+	}
+
+	buf := make([]byte, count)
+	n, err := f.file.ReadAt(buf, int64(offset))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return rread{data: buf[:n]}, nil
+}
+
+type rwrite struct{ count uint32 }
+
+func (r rwrite) typ() uint8 { return msgRwrite }
+func (r rwrite) encode() []byte {
+	e := newEncoder()
+	e.u32(r.count)
+	return e.buf
+}
+
+func (c *conn) twrite(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	offset := d.u64()
+	count := d.u32()
+	data := d.bytes(int(count))
+	if d.err != nil {
+		return nil, d.err
+	}
+	if c.srv.opts.ReadOnly {
+		return nil, errors.New("ninep: export is read-only")
+	}
+
+	c.mu.Lock()
+	f, ok := c.fids[fidNum]
+	c.mu.Unlock()
+	if !ok || !f.opened || f.file == nil {
+		return nil, fmt.Errorf("ninep: fid %d not open for write", fidNum)
+	}
+
+	n, err := f.file.WriteAt(data, int64(offset))
+	if err != nil {
+		return nil, err
+	}
+	return rwrite{count: uint32(n)}, nil
+}
+
+type rclunk struct{}
+
+func (r rclunk) typ() uint8     { return msgRclunk }
+func (r rclunk) encode() []byte { return nil }
+
+func (c *conn) tclunk(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	if d.err != nil {
+		return nil, d.err
+	}
+	c.mu.Lock()
+	f, ok := c.fids[fidNum]
+	delete(c.fids, fidNum)
+	c.mu.Unlock()
+	if ok && f.file != nil {
+		f.file.Close()
+	}
+	return rclunk{}, nil
+}
+
+type rremove struct{}
+
+func (r rremove) typ() uint8     { return msgRremove }
+func (r rremove) encode() []byte { return nil }
+
+func (c *conn) tremove(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	c.mu.Lock()
+	f, ok := c.fids[fidNum]
+	delete(c.fids, fidNum)
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ninep: unknown fid %d", fidNum)
+	}
+	if f.file != nil {
+		f.file.Close()
+	}
+	if c.srv.opts.ReadOnly {
+		return nil, errors.New("ninep: export is read-only")
+	}
+	if f.rel == "" {
+		return nil, errors.New("ninep: cannot remove export root")
+	}
+	if err := os.Remove(filepath.Join(c.srv.root, f.rel)); err != nil {
+		return nil, err
+	}
+	return rremove{}, nil
+}
+
+type rstat struct{ stat Stat }
+
+func (r rstat) typ() uint8 { return msgRstat }
+func (r rstat) encode() []byte {
+	// Per stat(5), Rstat's body is n[2] stat[n]: the stat's own encode
+	// already carries its internal size[2], but Rstat wraps that whole
+	// entry in a second, outer count.
+	stat := newEncoder()
+	r.stat.encode(stat)
+	e := newEncoder()
+	e.u16(uint16(len(stat.buf)))
+	e.bytes(stat.buf)
+	return e.buf
+}
+
+func (c *conn) tstat(d *decoder) (reply, error) {
+	fidNum := d.u32()
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	c.mu.Lock()
+	f, ok := c.fids[fidNum]
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ninep: unknown fid %d", fidNum)
+	}
+
+	abs := filepath.Join(c.srv.root, f.rel)
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return nil, err
+	}
+	return rstat{stat: c.srv.statFor(abs, info)}, nil
+}
+
+// --- helpers ---
+
+// resolveJailed evaluates any symlinks in abs (which must already exist)
+// and rejects the result if it resolves outside root. Twalk's per-
+// component Lstat keeps fids from being *constructed* across a symlink
+// that climbs out, but that's not enough on its own: a symlink can also
+// sit inside the export pointing straight at an arbitrary absolute path
+// (e.g. /etc/passwd), and following it is exactly what os.OpenFile does.
+// Callers that are about to open a path for real I/O resolve it through
+// here first.
+func resolveJailed(root, abs string) (string, error) {
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, real)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("ninep: path escapes export root")
+	}
+	return real, nil
+}
+
+// joinRel appends name to rel the way Twalk wants: "." and ".." resolve
+// normally, but nothing is allowed to climb above the export root.
+func joinRel(rel, name string) (string, error) {
+	switch name {
+	case ".":
+		return rel, nil
+	case "..":
+		if rel == "" {
+			return "", nil
+		}
+		parent := filepath.ToSlash(filepath.Dir(rel))
+		if parent == "." {
+			return "", nil
+		}
+		return parent, nil
+	default:
+		if strings.Contains(name, "/") {
+			return "", fmt.Errorf("ninep: invalid walk element %q", name)
+		}
+	}
+	if rel == "" {
+		return name, nil
+	}
+	joined := rel + "/" + name
+	cleaned := filepath.ToSlash(filepath.Clean(joined))
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("ninep: walk %q escapes root", joined)
+	}
+	return cleaned, nil
+}
+
+func (s *Server) statFor(abs string, info os.FileInfo) Stat {
+	mode := uint32(info.Mode().Perm())
+	if info.IsDir() {
+		mode |= DMDIR
+	}
+	return Stat{
+		Qid:    s.qidFor(abs, info),
+		Mode:   mode,
+		Mtime:  uint32(info.ModTime().Unix()),
+		Length: uint64(info.Size()),
+		Name:   filepath.Base(abs),
+		UID:    "cam",
+		GID:    "cam",
+	}
+}
+
+// encodeDirStats pre-renders every entry of a directory as one contiguous
+// buffer of size-prefixed Stat blobs, the format Tread expects for a fid
+// opened on a directory. Reads then just slice this buffer by offset.
+func encodeDirStats(s *Server, abs string, entries []os.DirEntry) []byte {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	e := newEncoder()
+	for _, d := range entries {
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		s.statFor(filepath.Join(abs, d.Name()), info).encode(e)
+	}
+	return e.buf
+}
+
+// sliceAt returns up to count bytes of buf starting at offset, or nil
+// past the end — the same semantics as reading a regular file.
+func sliceAt(buf []byte, offset uint64, count uint32) []byte {
+	if offset >= uint64(len(buf)) {
+		return nil
+	}
+	end := offset + uint64(count)
+	if end > uint64(len(buf)) {
+		end = uint64(len(buf))
+	}
+	return buf[offset:end]
+}
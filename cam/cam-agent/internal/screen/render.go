@@ -0,0 +1,164 @@
+package screen
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// CellJSON is the wire form of a Cell for the "cells" capture format.
+type CellJSON struct {
+	Ch        string `json:"ch"`
+	Fg        int    `json:"fg"`
+	Bg        int    `json:"bg"`
+	Bold      bool   `json:"bold,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+	Reverse   bool   `json:"reverse,omitempty"`
+	Italic    bool   `json:"italic,omitempty"`
+}
+
+// Snapshot is the full JSON payload for "session capture --format cells":
+// the grid plus everything about the screen a driver can't get from text
+// alone (cursor position, visibility, alt-screen state, title).
+type Snapshot struct {
+	Rows          int          `json:"rows"`
+	Cols          int          `json:"cols"`
+	CursorRow     int          `json:"cursor_row"`
+	CursorCol     int          `json:"cursor_col"`
+	CursorVisible bool         `json:"cursor_visible"`
+	AltScreen     bool         `json:"alt_screen"`
+	Title         string       `json:"title"`
+	Cells         [][]CellJSON `json:"cells"`
+}
+
+// Snapshot renders the terminal's current state as JSON-ready data.
+func (t *Terminal) Snapshot() Snapshot {
+	cells := make([][]CellJSON, t.Rows)
+	for r, row := range t.grid {
+		out := make([]CellJSON, len(row))
+		for c, cell := range row {
+			out[c] = CellJSON{
+				Ch:        string(cell.Ch),
+				Fg:        cell.Fg,
+				Bg:        cell.Bg,
+				Bold:      cell.Bold,
+				Underline: cell.Underline,
+				Reverse:   cell.Reverse,
+				Italic:    cell.Italic,
+			}
+		}
+		cells[r] = out
+	}
+	return Snapshot{
+		Rows: t.Rows, Cols: t.Cols,
+		CursorRow: t.row, CursorCol: t.col,
+		CursorVisible: t.cursorVisible,
+		AltScreen:     t.altScreen,
+		Title:         t.title,
+		Cells:         cells,
+	}
+}
+
+// ansi16 is the standard xterm 16-color palette, used to render indices
+// 0-15 in HTML. Indices 16-255 fall back to the 6x6x6 cube / grayscale
+// ramp approximation in paletteColor.
+var ansi16 = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00",
+	"#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00",
+	"#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+func paletteColor(idx int) string {
+	if idx < 0 {
+		return ""
+	}
+	if idx < 16 {
+		return ansi16[idx]
+	}
+	if idx < 232 {
+		idx -= 16
+		r := (idx / 36) * 51
+		g := ((idx / 6) % 6) * 51
+		b := (idx % 6) * 51
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	}
+	gray := 8 + (idx-232)*10
+	return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+}
+
+// HTML renders the grid as a <pre> block, one span per maximal run of
+// cells sharing the same style (runs in the default style are written as
+// bare text), suitable for embedding in a dashboard.
+func (t *Terminal) HTML() string {
+	var b strings.Builder
+	b.WriteString(`<pre class="cam-term">`)
+	for r, row := range t.grid {
+		if r > 0 {
+			b.WriteByte('\n')
+		}
+		writeHTMLRow(&b, row)
+	}
+	b.WriteString("</pre>")
+	return b.String()
+}
+
+func writeHTMLRow(b *strings.Builder, row []Cell) {
+	i := 0
+	for i < len(row) {
+		j := i + 1
+		for j < len(row) && sameStyle(row[i], row[j]) {
+			j++
+		}
+		writeHTMLSpan(b, row[i], row[i:j])
+		i = j
+	}
+}
+
+func sameStyle(a, b Cell) bool {
+	return a.Fg == b.Fg && a.Bg == b.Bg && a.Bold == b.Bold &&
+		a.Underline == b.Underline && a.Reverse == b.Reverse && a.Italic == b.Italic
+}
+
+func writeHTMLSpan(b *strings.Builder, style Cell, run []Cell) {
+	var text strings.Builder
+	for _, c := range run {
+		text.WriteRune(c.Ch)
+	}
+
+	css := cellCSS(style)
+	if css == "" {
+		b.WriteString(html.EscapeString(text.String()))
+		return
+	}
+	b.WriteString(`<span style="`)
+	b.WriteString(css)
+	b.WriteString(`">`)
+	b.WriteString(html.EscapeString(text.String()))
+	b.WriteString(`</span>`)
+}
+
+func cellCSS(c Cell) string {
+	fg, bg := c.Fg, c.Bg
+	if c.Reverse {
+		fg, bg = bg, fg
+	}
+
+	var parts []string
+	if fg >= 0 {
+		parts = append(parts, "color:"+paletteColor(fg))
+	}
+	if bg >= 0 {
+		parts = append(parts, "background-color:"+paletteColor(bg))
+	}
+	if c.Bold {
+		parts = append(parts, "font-weight:bold")
+	}
+	if c.Italic {
+		parts = append(parts, "font-style:italic")
+	}
+	if c.Underline {
+		parts = append(parts, "text-decoration:underline")
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,511 @@
+package screen
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Cell is one position in a Terminal's grid: a rune plus the SGR
+// attributes it was written with. Fg/Bg are ANSI color indices in
+// [0,255]; -1 means "use the default color".
+type Cell struct {
+	Ch        rune
+	Fg        int
+	Bg        int
+	Bold      bool
+	Underline bool
+	Reverse   bool
+	Italic    bool
+}
+
+func blankCell() Cell { return Cell{Ch: ' ', Fg: -1, Bg: -1} }
+
+// parserState is which part of an escape sequence Feed is currently
+// inside of.
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateCharset
+	stateCSI
+	stateOSC
+)
+
+// Terminal is a fixed rows×cols VT100-ish cell grid, fed by Feed. It
+// tracks enough state (cursor, SGR attributes, alternate screen, DEC
+// private modes, OSC title) for a driver to understand a captured tmux
+// pane the way a human looking at a real terminal would.
+type Terminal struct {
+	Rows, Cols int
+
+	primary [][]Cell
+	alt     [][]Cell
+	grid    [][]Cell // points at primary or alt
+	wrapped []bool   // per-row in the active grid: did this row soft-wrap?
+
+	row, col           int
+	savedRow, savedCol int
+
+	cursorVisible bool
+	altScreen     bool
+	title         string
+
+	fg, bg                         int
+	bold, underline, reverse, ital bool
+
+	state  parserState
+	params []byte
+	osc    []byte
+}
+
+// NewTerminal returns a blank rows×cols terminal, cursor at the origin.
+func NewTerminal(rows, cols int) *Terminal {
+	if rows <= 0 {
+		rows = 24
+	}
+	if cols <= 0 {
+		cols = 80
+	}
+	t := &Terminal{
+		Rows: rows, Cols: cols,
+		cursorVisible: true,
+		fg:            -1,
+		bg:            -1,
+	}
+	t.primary = newGrid(rows, cols)
+	t.grid = t.primary
+	t.wrapped = make([]bool, rows)
+	return t
+}
+
+func newGrid(rows, cols int) [][]Cell {
+	g := make([][]Cell, rows)
+	for r := range g {
+		row := make([]Cell, cols)
+		for c := range row {
+			row[c] = blankCell()
+		}
+		g[r] = row
+	}
+	return g
+}
+
+// Grid returns the active screen's cells. Callers must not mutate it.
+func (t *Terminal) Grid() [][]Cell { return t.grid }
+
+// Cursor returns the 0-based cursor row/column.
+func (t *Terminal) Cursor() (int, int) { return t.row, t.col }
+
+// CursorVisible reports whether DECTCEM is showing the cursor.
+func (t *Terminal) CursorVisible() bool { return t.cursorVisible }
+
+// AltScreen reports whether the alternate screen buffer is active.
+func (t *Terminal) AltScreen() bool { return t.altScreen }
+
+// Title is the most recent OSC 0/2 window title, if any.
+func (t *Terminal) Title() string { return t.title }
+
+// SetCursorMeta overrides the cursor/alt-screen/visible/title metadata
+// Snapshot reports, without touching the grid. A driver that feeds the
+// grid from a source that doesn't carry the escape sequences these
+// normally come from (e.g. a plain capture-pane text dump) can use this
+// to fill them in from wherever it actually has them (e.g. tmux's own
+// display-message variables), instead of leaving constant placeholders.
+func (t *Terminal) SetCursorMeta(row, col int, visible, altScreen bool, title string) {
+	t.row, t.col = row, col
+	t.cursorVisible = visible
+	t.altScreen = altScreen
+	t.title = title
+}
+
+// RowWrapped reports whether row soft-wrapped into the next row (as
+// opposed to ending with an explicit newline).
+func (t *Terminal) RowWrapped(row int) bool {
+	if row < 0 || row >= len(t.wrapped) {
+		return false
+	}
+	return t.wrapped[row]
+}
+
+// Feed parses data as a stream of text and escape sequences, updating
+// the grid, cursor and attribute state as it goes.
+func (t *Terminal) Feed(data []byte) {
+	i := 0
+	for i < len(data) {
+		b := data[i]
+		switch t.state {
+		case stateGround:
+			i += t.feedGround(data[i:])
+		case stateEscape:
+			i += t.feedEscape(data[i:])
+		case stateCharset:
+			t.state = stateGround
+			i++ // swallow the charset designator byte
+		case stateCSI:
+			i += t.feedCSI(b)
+		case stateOSC:
+			i += t.feedOSC(data[i:])
+		}
+	}
+}
+
+func (t *Terminal) feedGround(data []byte) int {
+	b := data[0]
+	switch {
+	case b == 0x1b:
+		t.state = stateEscape
+		return 1
+	case b == '\r':
+		t.col = 0
+		return 1
+	case b == '\n':
+		t.newline()
+		return 1
+	case b == '\b':
+		if t.col > 0 {
+			t.col--
+		}
+		return 1
+	case b == '\t':
+		next := (t.col/8 + 1) * 8
+		if next >= t.Cols {
+			next = t.Cols - 1
+		}
+		t.col = next
+		return 1
+	case b < 0x20:
+		return 1 // ignore other C0 controls
+	default:
+		r, size := utf8.DecodeRune(data)
+		t.putRune(r)
+		return size
+	}
+}
+
+func (t *Terminal) feedEscape(data []byte) int {
+	b := data[0]
+	switch b {
+	case '[':
+		t.state = stateCSI
+		t.params = t.params[:0]
+	case ']':
+		t.state = stateOSC
+		t.osc = t.osc[:0]
+	case '(', ')':
+		t.state = stateCharset
+	case '7':
+		t.savedRow, t.savedCol = t.row, t.col
+		t.state = stateGround
+	case '8':
+		t.row, t.col = t.savedRow, t.savedCol
+		t.state = stateGround
+	default:
+		t.state = stateGround // unrecognized single-char escape: swallow it
+	}
+	return 1
+}
+
+func (t *Terminal) feedCSI(b byte) int {
+	if (b >= 0x30 && b <= 0x3f) || b == ';' {
+		t.params = append(t.params, b)
+		return 1
+	}
+	if b >= 0x40 && b <= 0x7e {
+		t.handleCSI(string(t.params), b)
+		t.state = stateGround
+		return 1
+	}
+	// Intermediate bytes (0x20-0x2f) — rare in practice; buffer and ignore.
+	t.params = append(t.params, b)
+	return 1
+}
+
+func (t *Terminal) feedOSC(data []byte) int {
+	b := data[0]
+	if b == 0x07 {
+		t.handleOSC(string(t.osc))
+		t.state = stateGround
+		return 1
+	}
+	if b == 0x1b && len(data) > 1 && data[1] == '\\' {
+		t.handleOSC(string(t.osc))
+		t.state = stateGround
+		return 2
+	}
+	t.osc = append(t.osc, b)
+	return 1
+}
+
+// --- grid mutation ---
+
+func (t *Terminal) putRune(r rune) {
+	if t.col >= t.Cols {
+		t.wrapped[t.row] = true
+		t.col = 0
+		t.advanceRow()
+	}
+	t.grid[t.row][t.col] = Cell{
+		Ch: r, Fg: t.fg, Bg: t.bg,
+		Bold: t.bold, Underline: t.underline, Reverse: t.reverse, Italic: t.ital,
+	}
+	t.col++
+}
+
+func (t *Terminal) newline() {
+	t.col = 0
+	t.advanceRow()
+}
+
+func (t *Terminal) advanceRow() {
+	t.row++
+	if t.row >= t.Rows {
+		t.scrollUp()
+		t.row = t.Rows - 1
+	}
+}
+
+func (t *Terminal) scrollUp() {
+	copy(t.grid[0:], t.grid[1:])
+	copy(t.wrapped[0:], t.wrapped[1:])
+	blank := make([]Cell, t.Cols)
+	for i := range blank {
+		blank[i] = blankCell()
+	}
+	t.grid[t.Rows-1] = blank
+	t.wrapped[t.Rows-1] = false
+}
+
+func (t *Terminal) clearRow(row, from, to int) {
+	for c := from; c <= to && c < t.Cols; c++ {
+		t.grid[row][c] = blankCell()
+	}
+}
+
+// --- CSI ---
+
+func (t *Terminal) handleCSI(params string, final byte) {
+	private := strings.HasPrefix(params, "?")
+	if private {
+		params = params[1:]
+	}
+	nums := parseParams(params)
+
+	switch final {
+	case 'H', 'f':
+		t.row = clamp(numOr(nums, 0, 1)-1, 0, t.Rows-1)
+		t.col = clamp(numOr(nums, 1, 1)-1, 0, t.Cols-1)
+	case 'A':
+		t.row = clamp(t.row-numOr(nums, 0, 1), 0, t.Rows-1)
+	case 'B':
+		t.row = clamp(t.row+numOr(nums, 0, 1), 0, t.Rows-1)
+	case 'C':
+		t.col = clamp(t.col+numOr(nums, 0, 1), 0, t.Cols-1)
+	case 'D':
+		t.col = clamp(t.col-numOr(nums, 0, 1), 0, t.Cols-1)
+	case 'J':
+		t.eraseDisplay(numOr(nums, 0, 0))
+	case 'K':
+		t.eraseLine(numOr(nums, 0, 0))
+	case 'm':
+		t.handleSGR(nums)
+	case 'h', 'l':
+		if private {
+			t.handleDECMode(nums, final == 'h')
+		}
+	default:
+		// Scroll regions, device status reports, etc. — not needed for
+		// a point-in-time capture snapshot.
+	}
+}
+
+func (t *Terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.clearRow(t.row, t.col, t.Cols-1)
+		for r := t.row + 1; r < t.Rows; r++ {
+			t.clearRow(r, 0, t.Cols-1)
+		}
+	case 1:
+		for r := 0; r < t.row; r++ {
+			t.clearRow(r, 0, t.Cols-1)
+		}
+		t.clearRow(t.row, 0, t.col)
+	case 2, 3:
+		for r := 0; r < t.Rows; r++ {
+			t.clearRow(r, 0, t.Cols-1)
+		}
+	}
+}
+
+func (t *Terminal) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		t.clearRow(t.row, t.col, t.Cols-1)
+	case 1:
+		t.clearRow(t.row, 0, t.col)
+	case 2:
+		t.clearRow(t.row, 0, t.Cols-1)
+	}
+}
+
+func (t *Terminal) handleSGR(nums []int) {
+	if len(nums) == 0 {
+		nums = []int{0}
+	}
+	for i := 0; i < len(nums); i++ {
+		n := nums[i]
+		switch {
+		case n == 0:
+			t.fg, t.bg, t.bold, t.underline, t.reverse, t.ital = -1, -1, false, false, false, false
+		case n == 1:
+			t.bold = true
+		case n == 3:
+			t.ital = true
+		case n == 4:
+			t.underline = true
+		case n == 7:
+			t.reverse = true
+		case n == 22:
+			t.bold = false
+		case n == 23:
+			t.ital = false
+		case n == 24:
+			t.underline = false
+		case n == 27:
+			t.reverse = false
+		case n >= 30 && n <= 37:
+			t.fg = n - 30
+		case n == 38:
+			if v, adv := extendedColor(nums[i+1:]); v >= 0 {
+				t.fg = v
+				i += adv
+			}
+		case n == 39:
+			t.fg = -1
+		case n >= 40 && n <= 47:
+			t.bg = n - 40
+		case n == 48:
+			if v, adv := extendedColor(nums[i+1:]); v >= 0 {
+				t.bg = v
+				i += adv
+			}
+		case n == 49:
+			t.bg = -1
+		case n >= 90 && n <= 97:
+			t.fg = n - 90 + 8
+		case n >= 100 && n <= 107:
+			t.bg = n - 100 + 8
+		}
+	}
+}
+
+// extendedColor parses the "5;N" (256-color) or "2;R;G;B" (truecolor,
+// downsampled to its closest index in the 6x6x6 color cube) forms that can follow an
+// SGR 38/48 code. Returns the resolved palette index and how many of
+// rest it consumed.
+func extendedColor(rest []int) (int, int) {
+	if len(rest) == 0 {
+		return -1, 0
+	}
+	switch rest[0] {
+	case 5:
+		if len(rest) >= 2 {
+			return rest[1], 2
+		}
+	case 2:
+		if len(rest) >= 4 {
+			return rgbTo256(rest[1], rest[2], rest[3]), 4
+		}
+	}
+	return -1, 0
+}
+
+func rgbTo256(r, g, b int) int {
+	// 6x6x6 color cube starting at index 16.
+	qr, qg, qb := (r*5+127)/255, (g*5+127)/255, (b*5+127)/255
+	return 16 + 36*qr + 6*qg + qb
+}
+
+func (t *Terminal) handleDECMode(nums []int, set bool) {
+	for _, n := range nums {
+		switch n {
+		case 25:
+			t.cursorVisible = set
+		case 1049, 1047, 47:
+			t.setAltScreen(set)
+		}
+	}
+}
+
+func (t *Terminal) setAltScreen(on bool) {
+	if on == t.altScreen {
+		return
+	}
+	if on {
+		t.savedRow, t.savedCol = t.row, t.col
+		if t.alt == nil {
+			t.alt = newGrid(t.Rows, t.Cols)
+		}
+		t.grid = t.alt
+		t.row, t.col = 0, 0
+		t.altScreen = true
+	} else {
+		t.grid = t.primary
+		t.row, t.col = t.savedRow, t.savedCol
+		t.altScreen = false
+	}
+}
+
+// --- OSC ---
+
+func (t *Terminal) handleOSC(payload string) {
+	idx := strings.IndexByte(payload, ';')
+	if idx < 0 {
+		return
+	}
+	code, text := payload[:idx], payload[idx+1:]
+	if code == "0" || code == "2" {
+		t.title = text
+	}
+}
+
+// --- small helpers ---
+
+func parseParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ";")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		if p == "" {
+			nums[i] = 0
+			continue
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			n = 0
+		}
+		nums[i] = n
+	}
+	return nums
+}
+
+func numOr(nums []int, idx, def int) int {
+	if idx >= len(nums) || nums[idx] == 0 {
+		return def
+	}
+	return nums[idx]
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,29 @@
+package session
+
+// LayoutSpec describes a declarative, multi-window, multi-pane session
+// layout, as loaded from a YAML manifest by internal/manifest and applied
+// by Manager.CreateLayout. It generalizes Create, which only ever spawns
+// one window running one command.
+type LayoutSpec struct {
+	ID       string
+	Workdir  string
+	EnvSetup string
+	Windows  []WindowSpec
+}
+
+// WindowSpec is one tmux window: a tmux layout name (e.g. "tiled",
+// "even-horizontal", "main-vertical") plus the panes arranged under it.
+type WindowSpec struct {
+	Name   string
+	Layout string
+	Panes  []PaneSpec
+}
+
+// PaneSpec is one pane within a window.
+type PaneSpec struct {
+	Command string
+	Cwd     string
+	// SendKeys are keystroke lines sent to the pane, each followed by
+	// Enter, once the pane's command has started.
+	SendKeys []string
+}
@@ -4,11 +4,16 @@ package session
 // Linux uses real tmux; Windows will use ConPTY.
 type Manager interface {
 	Create(id string, cmd []string, workdir string, envSetup string) error
+	CreateLayout(id string, spec LayoutSpec) error
 	Exists(id string) bool
 	Kill(id string) error
 	Capture(id string, lines int) (string, error)
+	CaptureFormatted(id string, lines int, format string) (string, error)
+	CapturePane(id string, paneRef string, lines int) (string, error)
 	SendText(id string, text string, enter bool) error
+	SendTextPane(id string, paneRef string, text string, enter bool) error
 	SendKey(id string, key string) error
+	Resize(id string, rows, cols int) error
 	StartLogging(id string) (string, error)
 	ReadLog(id string, offset int64, maxBytes int) ([]byte, int64, error)
 }
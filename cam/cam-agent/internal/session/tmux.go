@@ -1,6 +1,7 @@
 package session
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -44,16 +45,8 @@ func (m *TmuxManager) Create(id string, cmdArgs []string, workdir string, envSet
 	// Ensure socket directory exists
 	os.MkdirAll(socketDir, 0755)
 
-	// Build the shell command.
-	// Wrap in login shell so user's env is loaded; include env_setup if given.
 	cmdStr := strings.Join(quoteArgs(cmdArgs), " ")
-	var shellCmd string
-	if envSetup != "" {
-		shellCmd = envSetup + " && exec " + cmdStr
-	} else {
-		shellCmd = "exec " + cmdStr
-	}
-	wrapped := fmt.Sprintf("bash -l -c %s", shellQuote(shellCmd))
+	wrapped := m.wrapCommand(cmdStr, envSetup)
 
 	// Create detached tmux session. Session dies when process exits.
 	args := []string{
@@ -69,6 +62,110 @@ func (m *TmuxManager) Create(id string, cmdArgs []string, workdir string, envSet
 	return nil
 }
 
+// CreateLayout builds a multi-window, multi-pane tmux session from a
+// declarative LayoutSpec (see cam-agent session apply): the first
+// window's first pane becomes the new-session, each later window's first
+// pane a new-window, every other pane a split-window, and each window is
+// arranged with select-layout. Unlike
+// Create, which only ever spawns one window running one command, this
+// brings up an entire dev setup — editor, server, logs — in one call.
+func (m *TmuxManager) CreateLayout(id string, spec LayoutSpec) error {
+	if len(spec.Windows) == 0 {
+		return fmt.Errorf("createlayout: spec has no windows")
+	}
+	os.MkdirAll(socketDir, 0755)
+
+	for wi, win := range spec.Windows {
+		if len(win.Panes) == 0 {
+			return fmt.Errorf("createlayout: window %d (%q) has no panes", wi, win.Name)
+		}
+
+		winTarget, err := m.createWindow(id, wi, win, spec)
+		if err != nil {
+			return err
+		}
+
+		layout := win.Layout
+		if layout == "" {
+			layout = "tiled"
+		}
+		if _, err := m.runTmux(id, "select-layout", "-t", winTarget, layout); err != nil {
+			return fmt.Errorf("select-layout failed: %w", err)
+		}
+
+		winName := strings.TrimPrefix(winTarget, id+":")
+		for pi, pane := range win.Panes {
+			paneRef := fmt.Sprintf("%s.%d", winName, pi)
+			for _, keys := range pane.SendKeys {
+				if err := m.SendTextPane(id, paneRef, keys, true); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	m.runTmux(id, "set-option", "-t", id, "history-limit", "50000")
+	return nil
+}
+
+// createWindow creates window wi of spec — the first window via
+// new-session, subsequent ones via new-window — and a pane via
+// split-window for every pane in win beyond the first. It returns the
+// window's tmux target, e.g. "mysession:editor".
+func (m *TmuxManager) createWindow(id string, wi int, win WindowSpec, spec LayoutSpec) (string, error) {
+	name := win.Name
+	if name == "" {
+		name = strconv.Itoa(wi)
+	}
+
+	first := win.Panes[0]
+	wrapped := m.wrapCommand(first.Command, spec.EnvSetup)
+	cwd := first.Cwd
+	if cwd == "" {
+		cwd = spec.Workdir
+	}
+
+	if wi == 0 {
+		args := []string{"new-session", "-d", "-s", id, "-n", name, "-c", cwd, wrapped}
+		if _, err := m.runTmux(id, args...); err != nil {
+			return "", fmt.Errorf("tmux new-session failed: %w", err)
+		}
+	} else {
+		args := []string{"new-window", "-d", "-t", id, "-n", name, "-c", cwd, wrapped}
+		if _, err := m.runTmux(id, args...); err != nil {
+			return "", fmt.Errorf("tmux new-window failed: %w", err)
+		}
+	}
+
+	winTarget := id + ":" + name
+	for _, pane := range win.Panes[1:] {
+		wrapped := m.wrapCommand(pane.Command, spec.EnvSetup)
+		cwd := pane.Cwd
+		if cwd == "" {
+			cwd = spec.Workdir
+		}
+		args := []string{"split-window", "-d", "-t", winTarget, "-c", cwd, wrapped}
+		if _, err := m.runTmux(id, args...); err != nil {
+			return "", fmt.Errorf("tmux split-window failed: %w", err)
+		}
+	}
+
+	return winTarget, nil
+}
+
+// wrapCommand builds the "bash -l -c ..." string run in every pane, a
+// login shell so the user's env is loaded, sourcing envSetup first if
+// one is given.
+func (m *TmuxManager) wrapCommand(command string, envSetup string) string {
+	var shellCmd string
+	if envSetup != "" {
+		shellCmd = envSetup + " && exec " + command
+	} else {
+		shellCmd = "exec " + command
+	}
+	return fmt.Sprintf("bash -l -c %s", shellQuote(shellCmd))
+}
+
 func (m *TmuxManager) Exists(id string) bool {
 	cmd := m.tmux(id, "has-session", "-t", id)
 	return cmd.Run() == nil
@@ -82,7 +179,13 @@ func (m *TmuxManager) Kill(id string) error {
 }
 
 func (m *TmuxManager) Capture(id string, lines int) (string, error) {
-	target := id + ":0.0"
+	return m.CapturePane(id, "0.0", lines)
+}
+
+// CapturePane is Capture against a specific window.pane target (e.g.
+// "1.0" or "editor.0") instead of the default pane "0.0".
+func (m *TmuxManager) CapturePane(id string, paneRef string, lines int) (string, error) {
+	target := id + ":" + paneRef
 	lineArg := fmt.Sprintf("-%d", lines)
 
 	// Primary capture
@@ -103,8 +206,121 @@ func (m *TmuxManager) Capture(id string, lines int) (string, error) {
 	return strings.TrimRight(screen.StripANSI(out), " \t\n\r"), nil
 }
 
-func (m *TmuxManager) SendText(id string, text string, enter bool) error {
+// CaptureFormatted is Capture plus three richer views built on a real VT
+// parser: "ansi" (raw escape sequences, unstripped), "cells" (a JSON cell
+// grid with attributes, cursor and alt-screen state) and "html" (the
+// same grid rendered as styled spans). format "" or "text" behaves
+// exactly like Capture.
+func (m *TmuxManager) CaptureFormatted(id string, lines int, format string) (string, error) {
+	if format == "" || format == "text" {
+		return m.Capture(id, lines)
+	}
+
 	target := id + ":0.0"
+	lineArg := fmt.Sprintf("-%d", lines)
+
+	// -e keeps escape sequences in the output; the VT parser needs them.
+	out, err := m.runTmux(id, "capture-pane", "-e", "-p", "-J", "-t", target, "-S", lineArg)
+	if err != nil {
+		return "", fmt.Errorf("capture-pane failed: %w", err)
+	}
+
+	if format == "ansi" {
+		return strings.TrimRight(out, "\n"), nil
+	}
+
+	rows, cols, err := m.paneSize(id)
+	if err != nil {
+		return "", err
+	}
+
+	term := screen.NewTerminal(rows, cols)
+	term.Feed([]byte(out))
+
+	// capture-pane's text dump carries none of the escape sequences
+	// Feed would otherwise learn cursor position, alt-screen state,
+	// visibility or title from, so pull them from tmux's own pane
+	// variables instead of leaving Snapshot's placeholders in place.
+	curRow, curCol, altScreen, cursorVisible, title, err := m.paneCursorMeta(id)
+	if err != nil {
+		return "", err
+	}
+	term.SetCursorMeta(curRow, curCol, cursorVisible, altScreen, title)
+
+	switch format {
+	case "cells":
+		data, err := json.Marshal(term.Snapshot())
+		if err != nil {
+			return "", fmt.Errorf("encode cells snapshot: %w", err)
+		}
+		return string(data), nil
+	case "html":
+		return term.HTML(), nil
+	default:
+		return "", fmt.Errorf("unknown capture format %q", format)
+	}
+}
+
+// paneSize returns the target pane's current rows and columns.
+func (m *TmuxManager) paneSize(id string) (rows, cols int, err error) {
+	target := id + ":0.0"
+	out, err := m.runTmux(id, "display-message", "-p", "-t", target, "#{pane_height}x#{pane_width}")
+	if err != nil {
+		return 0, 0, fmt.Errorf("display-message failed: %w", err)
+	}
+	out = strings.TrimSpace(out)
+	parts := strings.SplitN(out, "x", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("unexpected pane size output %q", out)
+	}
+	rows, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse pane height %q: %w", parts[0], err)
+	}
+	cols, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse pane width %q: %w", parts[1], err)
+	}
+	return rows, cols, nil
+}
+
+// paneCursorMeta returns the target pane's cursor position, alt-screen
+// state, cursor visibility and title, as tmux itself reports them. The
+// fields are joined with \x1f (not a character tmux's own values would
+// ever contain) so pane_title's spaces don't get mistaken for the
+// format's own field separator.
+func (m *TmuxManager) paneCursorMeta(id string) (row, col int, altScreen, cursorVisible bool, title string, err error) {
+	const sep = "\x1f"
+	target := id + ":0.0"
+	out, err := m.runTmux(id, "display-message", "-p", "-t", target,
+		strings.Join([]string{"#{cursor_y}", "#{cursor_x}", "#{alternate_on}", "#{cursor_flag}", "#{pane_title}"}, sep))
+	if err != nil {
+		return 0, 0, false, false, "", fmt.Errorf("display-message failed: %w", err)
+	}
+
+	parts := strings.SplitN(strings.TrimRight(out, "\n"), sep, 5)
+	if len(parts) != 5 {
+		return 0, 0, false, false, "", fmt.Errorf("unexpected pane cursor meta output %q", out)
+	}
+	row, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false, false, "", fmt.Errorf("parse cursor_y %q: %w", parts[0], err)
+	}
+	col, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false, false, "", fmt.Errorf("parse cursor_x %q: %w", parts[1], err)
+	}
+	return row, col, parts[2] == "1", parts[3] == "1", parts[4], nil
+}
+
+func (m *TmuxManager) SendText(id string, text string, enter bool) error {
+	return m.SendTextPane(id, "0.0", text, enter)
+}
+
+// SendTextPane is SendText against a specific window.pane target instead
+// of the default pane "0.0".
+func (m *TmuxManager) SendTextPane(id string, paneRef string, text string, enter bool) error {
+	target := id + ":" + paneRef
 
 	if text != "" {
 		if _, err := m.runTmux(id, "send-keys", "-t", target, "-l", "--", text); err != nil {
@@ -130,6 +346,16 @@ func (m *TmuxManager) SendKey(id string, key string) error {
 	return nil
 }
 
+// Resize changes the tmux window's size, e.g. to follow a remote
+// attach client's SIGWINCH.
+func (m *TmuxManager) Resize(id string, rows, cols int) error {
+	_, err := m.runTmux(id, "resize-window", "-t", id, "-x", strconv.Itoa(cols), "-y", strconv.Itoa(rows))
+	if err != nil {
+		return fmt.Errorf("resize-window failed: %w", err)
+	}
+	return nil
+}
+
 func (m *TmuxManager) StartLogging(id string) (string, error) {
 	os.MkdirAll(logDir, 0755)
 